@@ -0,0 +1,168 @@
+package bolt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AuthToken is the authentication token sent to the server when a connection
+// is opened. The constructors below build the schemes supported by Neo4j;
+// servers with custom authentication plugins can be sent any other scheme.
+type AuthToken struct {
+	// Scheme is the authentication scheme, e.g. "basic" or "bearer".
+	Scheme string
+
+	// Principal identifies who is authenticating, e.g. a username.
+	Principal string
+
+	// Credentials prove the principal's identity, e.g. a password, a token
+	// or a ticket.
+	Credentials string
+
+	// Realm is the authentication provider the credentials are checked
+	// against. It's omitted if empty.
+	Realm string
+
+	// Parameters are sent to custom authentication plugins. They're omitted
+	// if empty.
+	Parameters map[string]interface{}
+}
+
+// NoAuth returns an AuthToken for servers with authentication disabled.
+func NoAuth() AuthToken {
+	return AuthToken{Scheme: "none"}
+}
+
+// BasicAuth returns an AuthToken that authenticates with a username and
+// password. realm may be empty.
+func BasicAuth(username, password, realm string) AuthToken {
+	return AuthToken{
+		Scheme:      "basic",
+		Principal:   username,
+		Credentials: password,
+		Realm:       realm,
+	}
+}
+
+// BearerAuth returns an AuthToken that authenticates with a bearer token, such
+// as one issued by an OpenID Connect provider for Neo4j's SSO. Only the scheme
+// and the token are sent.
+func BearerAuth(token string) AuthToken {
+	return AuthToken{Scheme: "bearer", Credentials: token}
+}
+
+// KerberosAuth returns an AuthToken that authenticates with a base64 encoded
+// Kerberos ticket.
+func KerberosAuth(ticket string) AuthToken {
+	return AuthToken{Scheme: "kerberos", Credentials: ticket}
+}
+
+// CustomAuth returns an AuthToken for a custom authentication plugin. realm
+// and params may be empty. Connection URIs can only set string parameters,
+// using auth_param_<name> parameters.
+func CustomAuth(scheme, principal, credentials, realm string, params map[string]interface{}) AuthToken {
+	return AuthToken{
+		Scheme:      scheme,
+		Principal:   principal,
+		Credentials: credentials,
+		Realm:       realm,
+		Parameters:  params,
+	}
+}
+
+// fields returns the token as it's sent with INIT or HELLO.
+func (a AuthToken) fields() map[string]interface{} {
+	m := map[string]interface{}{"scheme": a.Scheme}
+	switch a.Scheme {
+	case "none":
+		return m
+	case "bearer":
+		m["credentials"] = a.Credentials
+		return m
+	}
+	m["principal"] = a.Principal
+	m["credentials"] = a.Credentials
+	if a.Realm != "" {
+		m["realm"] = a.Realm
+	}
+	if len(a.Parameters) > 0 {
+		m["parameters"] = a.Parameters
+	}
+	return m
+}
+
+// authToken returns the AuthToken used to open connections with cfg.
+func (cfg Config) authToken() AuthToken {
+	switch {
+	case cfg.Auth != nil:
+		return *cfg.Auth
+	case cfg.Username == "":
+		return NoAuth()
+	default:
+		return BasicAuth(cfg.Username, cfg.Password, "")
+	}
+}
+
+// authParamPrefix prefixes the DSN parameters holding a custom scheme's
+// parameters, e.g. auth_param_tenant=acme.
+const authParamPrefix = "auth_param_"
+
+// parseAuth returns the AuthToken described by the auth parameters of v, or
+// nil if the username and password should be used.
+func parseAuth(v values) (*AuthToken, error) {
+	var (
+		scheme      = v.get("auth_scheme")
+		credentials = v.get("auth_credentials")
+		realm       = v.get("auth_realm")
+		params      map[string]interface{}
+	)
+	for k, vv := range v {
+		if strings.HasPrefix(k, authParamPrefix) {
+			if params == nil {
+				params = make(map[string]interface{})
+			}
+			params[strings.TrimPrefix(k, authParamPrefix)] = vv
+		}
+	}
+	switch scheme {
+	case "", "none", "basic", "bearer", "kerberos":
+		if params != nil {
+			return nil, errors.New("bolt: auth parameters require a custom auth_scheme")
+		}
+	}
+
+	var a AuthToken
+	switch scheme {
+	case "":
+		if credentials != "" {
+			return nil, errors.New("bolt: auth_credentials requires auth_scheme")
+		}
+		if realm == "" {
+			return nil, nil
+		}
+		a = BasicAuth(v.get("username"), v.get("password"), realm)
+	case "none":
+		a = NoAuth()
+	case "basic":
+		a = BasicAuth(v.get("username"), v.get("password"), realm)
+	case "bearer", "kerberos":
+		if credentials == "" {
+			return nil, fmt.Errorf("bolt: the %s auth scheme requires auth_credentials", scheme)
+		}
+		if realm != "" {
+			return nil, fmt.Errorf("bolt: auth_realm can't be used with the %s auth scheme", scheme)
+		}
+		if scheme == "bearer" {
+			a = BearerAuth(credentials)
+		} else {
+			a = KerberosAuth(credentials)
+		}
+	default:
+		if credentials == "" {
+			credentials = v.get("password")
+		}
+		a = CustomAuth(scheme, v.get("username"), credentials, realm, params)
+	}
+	return &a, nil
+}
@@ -0,0 +1,96 @@
+package bolt
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sermodigital/bolt/structures/messages"
+)
+
+func TestAuth_DSN(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	host := addr(srv)
+
+	for _, tc := range []struct {
+		dsn  string
+		want map[string]interface{}
+	}{
+		{"bolt://" + host, map[string]interface{}{"scheme": "none"}},
+		{"bolt://john:hunter2@" + host, map[string]interface{}{
+			"scheme": "basic", "principal": "john", "credentials": "hunter2",
+		}},
+		{"bolt://john:hunter2@" + host + "?auth_realm=ldap", map[string]interface{}{
+			"scheme": "basic", "principal": "john", "credentials": "hunter2", "realm": "ldap",
+		}},
+		{"bolt://" + host + "?auth_scheme=bearer&auth_credentials=abc.def.ghi", map[string]interface{}{
+			"scheme": "bearer", "credentials": "abc.def.ghi",
+		}},
+		{"bolt://" + host + "?auth_scheme=kerberos&auth_credentials=dGlja2V0", map[string]interface{}{
+			"scheme": "kerberos", "principal": "", "credentials": "dGlja2V0",
+		}},
+		{"bolt://john:hunter2@" + host + "?auth_scheme=none", map[string]interface{}{"scheme": "none"}},
+		{"bolt://john:hunter2@" + host + "?auth_scheme=plugin&auth_realm=acme", map[string]interface{}{
+			"scheme": "plugin", "principal": "john", "credentials": "hunter2", "realm": "acme",
+		}},
+		{"bolt://" + host + "?auth_scheme=plugin&auth_credentials=k&auth_param_tenant=a&auth_param_region=eu", map[string]interface{}{
+			"scheme": "plugin", "principal": "", "credentials": "k",
+			"parameters": map[string]interface{}{"tenant": "a", "region": "eu"},
+		}},
+	} {
+		n := len(srv.messages(messages.InitSignature))
+		c, err := Open(tc.dsn)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.dsn, err)
+		}
+		c.Close()
+		init := srv.messages(messages.InitSignature)
+		if len(init) != n+1 {
+			t.Fatalf("%s: wanted an INIT, got %d", tc.dsn, len(init)-n)
+		}
+		if got := init[n].fields[1]; !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s: wanted auth token %v, got %v", tc.dsn, tc.want, got)
+		}
+	}
+
+	for _, dsn := range []string{
+		"bolt://" + host + "?auth_scheme=bearer",
+		"bolt://" + host + "?auth_credentials=abc",
+		"bolt://" + host + "?auth_scheme=bearer&auth_credentials=abc&auth_realm=ldap",
+		"bolt://john:hunter2@" + host + "?auth_param_tenant=a",
+	} {
+		if c, err := Open(dsn); err == nil {
+			c.Close()
+			t.Fatalf("%s: wanted an error", dsn)
+		}
+	}
+}
+
+func TestAuth_Connector(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.version = version3_0
+	srv.setHandler(v3Handler)
+
+	auth := CustomAuth("plugin", "john", "s3cret", "acme", map[string]interface{}{"tenant": "a"})
+	cfg := Config{Host: "127.0.0.1", Port: port(srv), Username: "ignored", Auth: &auth}
+	c, err := NewConnector(cfg, nil).Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+
+	hello := srv.messages(messages.HelloSignature)[0].fields[0].(map[string]interface{})
+	want := map[string]interface{}{
+		"user_agent":  ClientID,
+		"scheme":      "plugin",
+		"principal":   "john",
+		"credentials": "s3cret",
+		"realm":       "acme",
+		"parameters":  map[string]interface{}{"tenant": "a"},
+	}
+	if !reflect.DeepEqual(hello, want) {
+		t.Fatalf("wanted HELLO %v, got %v", want, hello)
+	}
+}
@@ -0,0 +1,118 @@
+package bolt
+
+import (
+	"context"
+	"sync"
+)
+
+// Bookmarks holds the bookmarks of committed transactions. A transaction
+// started with a context returned by WithBookmarks waits until the server has
+// applied every transaction in its Bookmarks, so it observes their writes
+// even if it runs on another connection or cluster member. Once committed,
+// its own bookmark replaces the bookmarks it was started with.
+//
+// Bookmarks is safe for concurrent use.
+type Bookmarks struct {
+	mu   sync.Mutex
+	list []string
+}
+
+// NewBookmarks returns Bookmarks holding the given bookmarks, for example
+// ones received from another process.
+func NewBookmarks(bookmarks ...string) *Bookmarks {
+	b := &Bookmarks{}
+	for _, bm := range bookmarks {
+		b.add(bm)
+	}
+	return b
+}
+
+// Bookmarks returns the bookmarks.
+func (b *Bookmarks) Bookmarks() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.list...)
+}
+
+// replace replaces the bookmarks a transaction was started with by the
+// bookmark it committed.
+func (b *Bookmarks) replace(sent []string, bm string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	list := b.list[:0]
+	for _, old := range b.list {
+		if !contains(sent, old) {
+			list = append(list, old)
+		}
+	}
+	b.list = list
+	b.addLocked(bm)
+}
+
+func (b *Bookmarks) add(bm string) {
+	b.mu.Lock()
+	b.addLocked(bm)
+	b.mu.Unlock()
+}
+
+func (b *Bookmarks) addLocked(bm string) {
+	if bm != "" && !contains(b.list, bm) {
+		b.list = append(b.list, bm)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// bookmarksKey is used to access the Bookmarks of a context.
+type bookmarksKey struct{}
+
+// WithBookmarks returns a context.Context that causes transactions started
+// with it, including the implicit transactions of queries run outside of a
+// transaction, to send b's bookmarks to the server and to record their
+// bookmark in b once they're committed.
+//
+// Bookmarks are sent with BEGIN on every protocol version and with RUN as of
+// Bolt v3.
+func WithBookmarks(ctx context.Context, b *Bookmarks) context.Context {
+	return context.WithValue(ctx, bookmarksKey{}, b)
+}
+
+// sendBookmarks adds the bookmarks of ctx, if any, to the metadata sent with
+// RUN or BEGIN and remembers where the transaction's bookmark is recorded.
+func (c *conn) sendBookmarks(ctx context.Context, md map[string]interface{}) {
+	b, _ := ctx.Value(bookmarksKey{}).(*Bookmarks)
+	c.bookmarks = b
+	c.sentBookmarks = nil
+	if b == nil {
+		return
+	}
+	c.sentBookmarks = b.Bookmarks()
+	if len(c.sentBookmarks) == 0 {
+		return
+	}
+	list := make([]interface{}, len(c.sentBookmarks))
+	for i, bm := range c.sentBookmarks {
+		list[i] = bm
+	}
+	md["bookmarks"] = list
+	if c.version.major() < 3 {
+		// Servers prior to Neo4j 3.2 only read a single bookmark.
+		md["bookmark"] = c.sentBookmarks[len(c.sentBookmarks)-1]
+	}
+}
+
+// recordBookmark records the bookmark of the transaction that just committed.
+func (c *conn) recordBookmark(bm string) {
+	if c.bookmarks != nil {
+		c.bookmarks.replace(c.sentBookmarks, bm)
+		c.bookmarks = nil
+		c.sentBookmarks = nil
+	}
+}
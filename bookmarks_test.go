@@ -0,0 +1,205 @@
+package bolt
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/sermodigital/bolt/structures/messages"
+)
+
+// bookmarkHandler answers COMMIT and the PULL_ALL of auto-commit queries with
+// bookmarks.
+func bookmarkHandler() func(uint8, []interface{}) []interface{} {
+	var (
+		mu   sync.Mutex
+		last string // the last query run
+	)
+	return func(sig uint8, fields []interface{}) []interface{} {
+		success := func(md map[string]interface{}) []interface{} {
+			return []interface{}{messages.Success{Metadata: md}}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch sig {
+		case messages.CommitSignature:
+			return success(map[string]interface{}{"bookmark": "bm:1"})
+		case messages.RunSignature:
+			last, _ = fields[0].(string)
+			return success(map[string]interface{}{"fields": []interface{}{}})
+		case messages.PullAllSignature:
+			switch last {
+			case "COMMIT":
+				return success(map[string]interface{}{"bookmark": "bm:1"})
+			case "BEGIN", "ROLLBACK":
+				return success(map[string]interface{}{})
+			default:
+				return success(map[string]interface{}{"bookmark": "bm:2"})
+			}
+		default:
+			return v3Handler(sig, fields)
+		}
+	}
+}
+
+func TestBookmarks(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.version = version3_0
+	srv.setHandler(bookmarkHandler())
+
+	db, err := sql.Open(DefaultDriver, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	bm := NewBookmarks("bm:0")
+	ctx := WithBookmarks(context.Background(), bm)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	begin := srv.messages(messages.BeginSignature)[0].fields[0].(map[string]interface{})
+	if want := []interface{}{"bm:0"}; !reflect.DeepEqual(begin["bookmarks"], want) {
+		t.Fatalf("wanted BEGIN bookmarks %v, got %v", want, begin["bookmarks"])
+	}
+	if want := []string{"bm:1"}; !reflect.DeepEqual(bm.Bookmarks(), want) {
+		t.Fatalf("wanted bookmarks %v, got %v", want, bm.Bookmarks())
+	}
+
+	ctx, sum := WithSummary(ctx)
+	if _, err := db.ExecContext(ctx, "CREATE ()"); err != nil {
+		t.Fatal(err)
+	}
+	run := srv.messages(messages.RunSignature)[0].fields[2].(map[string]interface{})
+	if want := []interface{}{"bm:1"}; !reflect.DeepEqual(run["bookmarks"], want) {
+		t.Fatalf("wanted RUN bookmarks %v, got %v", want, run["bookmarks"])
+	}
+	if want := []string{"bm:2"}; !reflect.DeepEqual(bm.Bookmarks(), want) {
+		t.Fatalf("wanted bookmarks %v, got %v", want, bm.Bookmarks())
+	}
+	if b := sum().Bookmark; b != "bm:2" {
+		t.Fatalf("wanted summary bookmark bm:2, got %q", b)
+	}
+}
+
+func TestBookmarks_V1(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.setHandler(bookmarkHandler())
+
+	db, err := sql.Open(DefaultDriver, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	bm := NewBookmarks("bm:0")
+	tx, err := db.BeginTx(WithBookmarks(context.Background(), bm), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	begin := srv.messages(messages.RunSignature)[0]
+	params := begin.fields[1].(map[string]interface{})
+	if begin.fields[0] != "BEGIN" || params["bookmark"] != "bm:0" {
+		t.Fatalf("wanted BEGIN with bookmark bm:0, got %v", begin.fields)
+	}
+	if want := []string{"bm:1"}; !reflect.DeepEqual(bm.Bookmarks(), want) {
+		t.Fatalf("wanted bookmarks %v, got %v", want, bm.Bookmarks())
+	}
+}
+
+func TestBookmarks_Pipeline(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.version = version3_0
+	var (
+		mu    sync.Mutex
+		pulls int
+	)
+	srv.setHandler(func(sig uint8, fields []interface{}) []interface{} {
+		if sig != messages.PullAllSignature {
+			return v3Handler(sig, fields)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		pulls++
+		return []interface{}{messages.Success{Metadata: map[string]interface{}{
+			"bookmark": fmt.Sprintf("bm:%d", pulls),
+		}}}
+	})
+
+	c, err := OpenNeo(srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	bm := NewBookmarks("bm:0")
+	res, err := c.(Pipeliner).Pipeline(WithBookmarks(context.Background(), bm),
+		Statement{Query: "CREATE (:A)"},
+		Statement{Query: "CREATE (:B)"},
+		Statement{Query: "CREATE (:C)"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := res.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, run := range srv.messages(messages.RunSignature) {
+		md := run.fields[2].(map[string]interface{})
+		if want := []interface{}{"bm:0"}; !reflect.DeepEqual(md["bookmarks"], want) {
+			t.Fatalf("wanted RUN bookmarks %v, got %v", want, md["bookmarks"])
+		}
+	}
+	if want := []string{"bm:3"}; !reflect.DeepEqual(bm.Bookmarks(), want) {
+		t.Fatalf("wanted bookmarks %v, got %v", want, bm.Bookmarks())
+	}
+}
+
+func TestBookmarks_Query(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.version = version{0x00, 0x00, 0x04, 0x04}
+	srv.setHandler(func(sig uint8, fields []interface{}) []interface{} {
+		if sig != messages.PullSignature {
+			return v3Handler(sig, fields)
+		}
+		return []interface{}{
+			messages.Record{Values: []interface{}{int64(1)}},
+			messages.Success{Metadata: map[string]interface{}{
+				"bookmark": "bm:9",
+			}},
+		}
+	})
+
+	db, err := sql.Open(DefaultDriver, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	bm := NewBookmarks()
+	ctx, sum := WithSummary(WithBookmarks(context.Background(), bm))
+	var out int64
+	if err := db.QueryRowContext(ctx, "RETURN 1").Scan(&out); err != nil {
+		t.Fatal(err)
+	}
+	if b := sum().Bookmark; b != "bm:9" {
+		t.Fatalf("wanted summary bookmark bm:9, got %q", b)
+	}
+	if want := []string{"bm:9"}; !reflect.DeepEqual(bm.Bookmarks(), want) {
+		t.Fatalf("wanted bookmarks %v, got %v", want, bm.Bookmarks())
+	}
+}
@@ -0,0 +1,105 @@
+package bolt
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/sermodigital/bolt/structures/messages"
+)
+
+// interruptTimeout is how long the server is given to abort a query after its
+// context is done before the connection is closed.
+var interruptTimeout = 5 * time.Second
+
+// watchCancel watches ctx while a query is in flight. If ctx is done before
+// the returned function is called, a RESET is sent to make the server abort
+// the query. If the server doesn't respond within interruptTimeout the
+// underlying connection is closed, interrupting any blocked reads or writes.
+//
+// The returned function must be called once the query's responses have been
+// read or the query has failed. If ctx was done it reads any outstanding
+// responses, leaving the connection ready for reuse, and returns ctx.Err().
+func (c *conn) watchCancel(ctx context.Context) func() error {
+	if ctx.Done() == nil {
+		return func() error { return nil }
+	}
+
+	var (
+		stop     = make(chan struct{})
+		canceled = make(chan bool, 1)
+		sent     = make(chan struct{})
+		drained  = make(chan struct{})
+		exited   = make(chan struct{})
+	)
+	go func() {
+		defer close(exited)
+		select {
+		case <-stop:
+			canceled <- false
+			return
+		case <-ctx.Done():
+			canceled <- true
+		}
+		c.interrupt(sent, drained)
+	}()
+
+	return func() error {
+		close(stop)
+		if !<-canceled {
+			<-exited
+			return nil
+		}
+		<-sent
+		err := c.drain()
+		close(drained)
+		<-exited
+		if err != nil {
+			c.bad = true
+		} else if c.status == statusInTx {
+			// RESET ended the transaction.
+			c.status = statusInBadTx
+		}
+		return ctx.Err()
+	}
+}
+
+// interrupt sends a RESET and closes sent. It closes the connection if the
+// RESET can't be sent or drained isn't closed within interruptTimeout.
+func (c *conn) interrupt(sent chan<- struct{}, drained <-chan struct{}) {
+	t := time.NewTimer(interruptTimeout)
+	defer t.Stop()
+
+	select {
+	case c.wsem <- struct{}{}:
+		err := c.encodeLocked(messages.Reset{})
+		<-c.wsem
+		if err != nil {
+			c.conn.Close()
+			close(sent)
+			return
+		}
+		close(sent)
+	case <-t.C:
+		c.conn.Close()
+		close(sent)
+		return
+	}
+
+	select {
+	case <-drained:
+	case <-t.C:
+		c.conn.Close()
+	}
+}
+
+// drain discards responses until every message sent has been answered. Unlike
+// discard it doesn't acknowledge failures, which the RESET already cleared.
+func (c *conn) drain() error {
+	for atomic.LoadInt32(&c.pending) > 0 {
+		if _, err := c.decode(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
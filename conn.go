@@ -2,15 +2,19 @@ package bolt
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"database/sql"
 	"database/sql/driver"
 	"errors"
 	"fmt"
 	"io"
 	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/sermodigital/bolt/encoding"
+	"github.com/sermodigital/bolt/structures"
 	"github.com/sermodigital/bolt/structures/messages"
 )
 
@@ -18,6 +22,14 @@ import (
 // a failed transaction.
 var ErrInFailedTransaction = errors.New("bolt: operation inside failed transaction")
 
+// ErrDatabaseUnsupported is returned when a database is selected on a
+// connection that negotiated a protocol version prior to Bolt v4.
+var ErrDatabaseUnsupported = errors.New("bolt: database selection requires Bolt v4 or later")
+
+// ErrTxConfigUnsupported is returned when a transaction timeout or metadata
+// is set on a connection that negotiated a protocol version prior to Bolt v3.
+var ErrTxConfigUnsupported = errors.New("bolt: transaction timeout and metadata require Bolt v3 or later")
+
 // ErrStatementClosed is returned when an operation is attempted on a closed
 // statement.
 var ErrStatementClosed = errors.New("bolt: statement is closed")
@@ -32,6 +44,34 @@ const (
 	statusInBadTx               // in a bad transaction
 )
 
+// Conn is a connection to a Neo4j server. Its Neo methods are more efficient
+// than their database/sql counterparts: they accept parameters as a map and
+// return values as they were decoded, e.g. graph.Node, without converting them
+// to driver.Values.
+type Conn interface {
+	driver.Conn
+
+	// PrepareNeo prepares a query.
+	PrepareNeo(query string) (Stmt, error)
+
+	// QueryNeo runs a query that returns rows.
+	QueryNeo(query string, params map[string]interface{}) (Rows, error)
+
+	// QueryNeoAll runs a query and returns all of its rows along with the
+	// metadata of its RUN and PULL_ALL responses.
+	QueryNeoAll(query string, params map[string]interface{}) ([][]interface{}, map[string]interface{}, map[string]interface{}, error)
+
+	// ExecNeo runs a query, discarding any rows it returns.
+	ExecNeo(query string, params map[string]interface{}) (driver.Result, error)
+
+	// SetChunkSize sets the size of the chunks written to the connection.
+	SetChunkSize(uint16)
+
+	// SetTimeout sets the timeout for reading and writing to the
+	// connection.
+	SetTimeout(time.Duration)
+}
+
 type conn struct {
 	conn net.Conn
 	buf  *bufio.Reader
@@ -41,10 +81,31 @@ type conn struct {
 	dec *encoding.Decoder
 	enc *encoding.Encoder
 
+	// wsem is held while a message is being written. It allows a RESET to be
+	// written from another goroutine when a context is canceled.
+	wsem chan struct{}
+
+	// pending is the number of messages sent that have not been answered
+	// with a summary (SUCCESS, FAILURE, or IGNORED). It must be accessed
+	// atomically.
+	pending int32
+
 	timeout time.Duration
 	size    uint16
 	status  status
 	bad     bool
+	version version
+	server  string // server agent reported when the connection was opened
+
+	// database is the default database queries run against. If empty, the
+	// server's default database is used.
+	database string
+
+	// bookmarks is where the bookmark of the current transaction is recorded
+	// once it's committed, and sentBookmarks are the bookmarks it was
+	// started with. See WithBookmarks.
+	bookmarks     *Bookmarks
+	sentBookmarks []string
 }
 
 var (
@@ -53,15 +114,26 @@ var (
 	_ driver.ExecerContext      = (*conn)(nil)
 	_ driver.ConnBeginTx        = (*conn)(nil)
 	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+	_ Conn                      = (*conn)(nil)
 )
 
+// CheckNamedValue implements driver.NamedValueChecker.
+func (c *conn) CheckNamedValue(v *driver.NamedValue) error {
+	return checkNamedValue(v)
+}
+
 // QueryContext implements driver.QueryerContext.
 func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
 	params, err := driverArgsToMap(args)
 	if err != nil {
 		return nil, err
 	}
-	return c.query(ctx, query, params)
+	rows, err := c.query(ctx, query, params)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
 }
 
 // ExecContext implements driver.ExecerContext.
@@ -74,12 +146,56 @@ func (c *conn) ExecContext(ctx context.Context, query string, args []driver.Name
 }
 
 // BeginTx implements driver.ConnBeginTx.
+// Read-only transactions are started in read access mode, which a cluster
+// routes to its followers. Neo4j transactions are read committed, so only the
+// default and read committed isolation levels are accepted.
 func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
-	return c.begin()
+	switch sql.IsolationLevel(opts.Isolation) {
+	case sql.LevelDefault, sql.LevelReadCommitted:
+		// OK
+	default:
+		return nil, fmt.Errorf("bolt: unsupported isolation level: %s", sql.IsolationLevel(opts.Isolation))
+	}
+	return c.begin(ctx, opts.ReadOnly || accessModeFromContext(ctx) == ReadAccess)
 }
 
-// query is the common implementation of Query and QueryContext.
-func (c *conn) query(ctx context.Context, query string, args map[string]interface{}) (driver.Rows, error) {
+// PrepareNeo implements Conn.
+func (c *conn) PrepareNeo(query string) (Stmt, error) {
+	if c.bad {
+		return nil, driver.ErrBadConn
+	}
+	return &stmt{conn: c, query: query}, nil
+}
+
+// QueryNeo implements Conn.
+func (c *conn) QueryNeo(query string, params map[string]interface{}) (Rows, error) {
+	rows, err := c.query(context.Background(), query, params)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// QueryNeoAll implements Conn.
+func (c *conn) QueryNeoAll(query string, params map[string]interface{}) ([][]interface{}, map[string]interface{}, map[string]interface{}, error) {
+	rows, err := c.query(context.Background(), query, params)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	data, md, err := rows.All()
+	if err != nil {
+		return nil, nil, nil, multi(err, rows.Close())
+	}
+	return data, rows.Metadata(), md, rows.Close()
+}
+
+// ExecNeo implements Conn.
+func (c *conn) ExecNeo(query string, params map[string]interface{}) (driver.Result, error) {
+	return c.exec(context.Background(), query, params)
+}
+
+// query is the common implementation of Query, QueryContext, and QueryNeo.
+func (c *conn) query(ctx context.Context, query string, args map[string]interface{}) (*rows, error) {
 	if c.bad {
 		return nil, driver.ErrBadConn
 	}
@@ -116,6 +232,11 @@ func (c *conn) Close() error {
 	if c.bad {
 		return driver.ErrBadConn
 	}
+	if c.version.major() >= 3 {
+		// GOODBYE has no response and the socket is closed regardless, so
+		// there's nothing to be done if it fails.
+		c.encode(messages.Goodbye{})
+	}
 	c.status = statusIdle
 	err := c.conn.Close()
 	c.bad = err == nil
@@ -124,18 +245,37 @@ func (c *conn) Close() error {
 
 // Begin begins a new transaction. It helps implement driver.Conn.
 func (c *conn) Begin() (driver.Tx, error) {
-	return c.begin()
+	return c.begin(context.Background(), false)
 }
 
 // begin is the implementaiton of Begin and BeginTx.
-func (c *conn) begin() (driver.Tx, error) {
+func (c *conn) begin(ctx context.Context, readOnly bool) (driver.Tx, error) {
 	if c.bad {
 		return nil, driver.ErrBadConn
 	}
 	if err := c.checktx(false); err != nil {
 		return nil, err
 	}
-	if err := c.transac(begin); err != nil {
+	md := make(map[string]interface{})
+	if err := c.selectDatabase(ctx, md); err != nil {
+		return nil, err
+	}
+	if err := c.txConfig(ctx, md); err != nil {
+		return nil, err
+	}
+	c.sendBookmarks(ctx, md)
+	if readOnly && c.version.major() >= 3 {
+		md["mode"] = "r"
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	finish := c.watchCancel(ctx)
+	err := c.transac(begin, md)
+	if cerr := finish(); cerr != nil {
+		return nil, cerr
+	}
+	if err != nil {
 		return nil, err
 	}
 	c.status = statusInTx
@@ -156,7 +296,11 @@ func (c *conn) Commit() error {
 		}
 		return ErrInFailedTransaction
 	}
-	return c.transac(commit)
+	if err := c.transac(commit, nil); err != nil {
+		return err
+	}
+	c.status = statusIdle
+	return nil
 }
 
 // Rollback rolls back and closes the transaction. It helps implement driver.Tx.
@@ -168,7 +312,13 @@ func (c *conn) Rollback() error {
 	if err := c.checktx(true); err != nil {
 		return err
 	}
-	if err := c.transac(rollback); err != nil {
+	if c.version.major() >= 3 && c.status == statusInBadTx {
+		// The RESET sent after the failure already rolled back the
+		// transaction.
+		c.status = statusIdle
+		return nil
+	}
+	if err := c.transac(rollback, nil); err != nil {
 		return err
 	}
 	c.status = statusIdle
@@ -180,50 +330,105 @@ func (c *conn) Rollback() error {
 func (c *conn) decode() (interface{}, error) {
 	if c.dec == nil {
 		c.dec = encoding.NewDecoder(c)
+		c.dec.SetVersion(c.version.major(), c.version.minor())
 	}
 	if !c.dec.More() {
 		return nil, io.EOF
 	}
-	return c.dec.Decode()
+	resp, err := c.dec.Decode()
+	if err != nil {
+		return resp, err
+	}
+	switch resp := resp.(type) {
+	case messages.Success:
+		atomic.AddInt32(&c.pending, -1)
+		// Committing a transaction, explicitly or by consuming the results
+		// of an auto-commit query, reports its bookmark.
+		if bm, ok := resp.Metadata["bookmark"].(string); ok {
+			c.recordBookmark(bm)
+		}
+	case messages.Failure, messages.Ignored:
+		atomic.AddInt32(&c.pending, -1)
+	}
+	return resp, nil
 }
 
 // encode writes the bolt-encoded form of v to the connection.
 func (c *conn) encode(v interface{}) error {
+	c.wsem <- struct{}{}
+	defer func() { <-c.wsem }()
+	return c.encodeLocked(v)
+}
+
+// encodeAll writes the bolt-encoded form of every message in msgs to the
+// connection with a single write.
+func (c *conn) encodeAll(msgs ...interface{}) error {
+	var buf bytes.Buffer
+	enc := encoding.NewEncoder(&buf)
+	enc.SetChunkSize(c.size)
+	enc.SetVersion(c.version.major(), c.version.minor())
+	for _, msg := range msgs {
+		if err := enc.Encode(msg); err != nil {
+			return err
+		}
+	}
+
+	c.wsem <- struct{}{}
+	defer func() { <-c.wsem }()
+	if _, err := c.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	atomic.AddInt32(&c.pending, int32(len(msgs)))
+	return nil
+}
+
+// encodeLocked is like encode but requires wsem to be held.
+func (c *conn) encodeLocked(v interface{}) error {
 	if c.enc == nil {
 		c.enc = encoding.NewEncoder(c)
 		c.enc.SetChunkSize(c.size)
+		c.enc.SetVersion(c.version.major(), c.version.minor())
 	}
-	return c.enc.Encode(v)
-}
-
-// newConn creates a new Neo4j connection using the provided values.
-func newConn(netcn net.Conn, v values) (*conn, error) {
-	timeout, err := parseTimeout(v.get("timeout"))
-	if err != nil {
-		return nil, err
+	if err := c.enc.Encode(v); err != nil {
+		return err
+	}
+	// GOODBYE is the only message the server doesn't answer.
+	if _, ok := v.(messages.Goodbye); !ok {
+		atomic.AddInt32(&c.pending, 1)
 	}
+	return nil
+}
 
+// newConn creates a new Neo4j connection using the provided configuration.
+func newConn(netcn net.Conn, cfg Config) (*conn, error) {
 	c := &conn{
-		conn:    netcn,
-		buf:     bufio.NewReader(netcn),
-		timeout: timeout,
-		size:    encoding.DefaultChunkSize,
+		conn:     netcn,
+		buf:      bufio.NewReader(netcn),
+		wsem:     make(chan struct{}, 1),
+		timeout:  cfg.Timeout,
+		size:     encoding.DefaultChunkSize,
+		database: cfg.Database,
 	}
 	if err := c.handshake(); err != nil {
 		return nil, multi(err, c.Close())
 	}
+	if c.database != "" && c.version.major() < 4 {
+		return nil, multi(ErrDatabaseUnsupported, c.Close())
+	}
 
-	resp, err := c.sendInit(v.get("username"), v.get("password"))
+	resp, err := c.sendInit(cfg.authToken())
 	if err != nil {
 		return nil, multi(err, c.Close())
 	}
 
-	if _, ok := resp.(messages.Success); !ok {
+	success, ok := resp.(messages.Success)
+	if !ok {
 		return nil, multi(
 			UnrecognizedResponseErr{v: resp},
 			c.Close(),
 		)
 	}
+	c.server, _ = success.Metadata["server"].(string)
 	return c, nil
 }
 
@@ -236,10 +441,11 @@ func (c *conn) handshake() error {
 	if _, err := io.ReadFull(c, vers[:]); err != nil {
 		return err
 	}
-	switch vers {
-	case noSupportedVersions:
+	switch {
+	case vers == noSupportedVersions:
 		return errors.New("server does not support any versions")
-	case version1_0:
+	case vers.supported():
+		c.version = vers
 		return nil
 	default:
 		return fmt.Errorf("unknown version: %v", vers)
@@ -351,8 +557,9 @@ func (t txQuery) String() string {
 	}
 }
 
-// transac executes the given transaction query.
-func (c *conn) transac(query txQuery) error {
+// transac executes the given transaction query. md holds the metadata sent
+// with BEGIN as of Bolt v3, and the parameters of the BEGIN query before.
+func (c *conn) transac(query txQuery, md map[string]interface{}) error {
 	switch query {
 	case begin, commit, rollback:
 		// OK
@@ -360,8 +567,21 @@ func (c *conn) transac(query txQuery) error {
 		return fmt.Errorf("bug: invalid transaction query: %s", query)
 	}
 
-	run, pull, err := c.sendRunPullAllConsumeSingle(string(query), nil)
+	if c.version.major() >= 3 {
+		return c.transacMessage(query, md)
+	}
+
+	var params map[string]interface{}
+	if len(md) > 0 {
+		params = md
+	}
+	run, pull, err := c.sendRunPullAllConsumeSingle(string(query), params)
 	if err != nil {
+		// A BEGIN that failed to run didn't start a transaction. Otherwise
+		// the state of the transaction is unknown, whichever step failed.
+		if _, ok := err.(*Neo4jError); ok && (query != begin || run != nil) {
+			c.status = statusInBadTx
+		}
 		return err
 	}
 
@@ -378,6 +598,36 @@ func (c *conn) transac(query txQuery) error {
 	return nil
 }
 
+// transacMessage sends the explicit transaction message that corresponds to
+// query, which Bolt v3 uses in place of transaction queries.
+func (c *conn) transacMessage(query txQuery, md map[string]interface{}) error {
+	var msg structures.Structure
+	switch query {
+	case begin:
+		msg = messages.NewBeginMessage(md)
+	case commit:
+		msg = messages.Commit{}
+	case rollback:
+		msg = messages.Rollback{}
+	}
+	if err := c.encode(msg); err != nil {
+		return err
+	}
+
+	resp, err := c.consume()
+	if err != nil {
+		if _, ok := err.(*Neo4jError); ok && query != begin {
+			// The failure's RESET ended the transaction.
+			c.status = statusIdle
+		}
+		return err
+	}
+	if _, ok := resp.(messages.Success); !ok {
+		return UnrecognizedResponseErr{v: resp}
+	}
+	return nil
+}
+
 func (c *conn) checktx(intx bool) error {
 	if (c.status == statusInTx || c.status == statusInBadTx) != intx {
 		c.bad = true
@@ -397,21 +647,47 @@ func (c *conn) SetTimeout(timeout time.Duration) {
 }
 
 // consume returns the next value from the connection, acknowledging any
-// failures that occurred.
+// failures that occurred. Failures are returned as a *Neo4jError.
 func (c *conn) consume() (interface{}, error) {
 	resp, err := c.decode()
 	if err != nil {
 		return resp, err
 	}
 	if fail, ok := resp.(messages.Failure); ok {
-		if err := c.ackFailure(); err != nil {
+		if err := c.resolveFailure(); err != nil {
 			return nil, err
 		}
-		return fail, nil
+		return nil, newNeo4jError(fail)
 	}
 	return resp, err
 }
 
+// resolveFailure acknowledges a failure, allowing the connection to proceed.
+func (c *conn) resolveFailure() error {
+	if c.version.major() < 3 {
+		return c.ackFailure()
+	}
+	// Bolt v3 removed ACK_FAILURE in favor of RESET, which also rolls back
+	// any open transaction.
+	if c.status == statusInTx {
+		c.status = statusInBadTx
+	}
+	return c.reset()
+}
+
+// discard consumes responses until every message sent has been answered.
+// Failures are acknowledged but not reported.
+func (c *conn) discard() error {
+	for atomic.LoadInt32(&c.pending) > 0 {
+		if _, err := c.consume(); err != nil {
+			if _, ok := err.(*Neo4jError); !ok {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (c *conn) consumeAll() ([]interface{}, interface{}, error) {
 	var responses []interface{}
 	for {
@@ -427,39 +703,125 @@ func (c *conn) consumeAll() ([]interface{}, interface{}, error) {
 	}
 }
 
-func (c *conn) sendInit(user, pass string) (interface{}, error) {
-	initMessage := messages.NewInitMessage(ClientID, user, pass)
+func (c *conn) sendInit(auth AuthToken) (interface{}, error) {
+	var initMessage structures.Structure
+	if c.version.major() >= 3 {
+		initMessage = messages.NewHelloMessageWithToken(ClientID, auth.fields())
+	} else {
+		initMessage = messages.NewInitMessageWithToken(ClientID, auth.fields())
+	}
 	if err := c.encode(initMessage); err != nil {
 		return nil, err
 	}
-	return c.consume()
+	// The server closes the connection after a failed INIT or HELLO, so the
+	// failure is not acknowledged.
+	resp, err := c.decode()
+	if err != nil {
+		return nil, err
+	}
+	if fail, ok := resp.(messages.Failure); ok {
+		return nil, newNeo4jError(fail)
+	}
+	return resp, nil
 }
 
-func (c *conn) run(query string, args map[string]interface{}) error {
-	runMessage := messages.NewRunMessage(query, args)
-	return c.encode(runMessage)
+// selectDatabase adds the database selected by ctx, if any, to the metadata
+// sent with RUN or BEGIN.
+func (c *conn) selectDatabase(ctx context.Context, md map[string]interface{}) error {
+	db := databaseFromContext(ctx, c.database)
+	if db == "" {
+		return nil
+	}
+	if c.version.major() < 4 {
+		return ErrDatabaseUnsupported
+	}
+	md["db"] = db
+	return nil
+}
+
+// txConfig adds the transaction timeout and metadata set by ctx, if any, to
+// the metadata sent with RUN or BEGIN.
+func (c *conn) txConfig(ctx context.Context, md map[string]interface{}) error {
+	timeout, hasTimeout := ctx.Value(txTimeoutKey{}).(time.Duration)
+	txmd, hasMetadata := ctx.Value(txMetadataKey{}).(map[string]interface{})
+	if !hasTimeout && !hasMetadata {
+		return nil
+	}
+	if c.version.major() < 3 {
+		return ErrTxConfigUnsupported
+	}
+	if hasTimeout {
+		md["tx_timeout"] = int64(timeout / time.Millisecond)
+	}
+	if hasMetadata {
+		md["tx_metadata"] = txmd
+	}
+	return nil
+}
+
+// runMetadata returns the metadata sent with RUN for a query executed with
+// ctx.
+func (c *conn) runMetadata(ctx context.Context) (map[string]interface{}, error) {
+	md := make(map[string]interface{})
+	// Inside of a transaction the database and configuration have already
+	// been sent with BEGIN.
+	if c.status == statusIdle {
+		if err := c.selectDatabase(ctx, md); err != nil {
+			return nil, err
+		}
+		if err := c.txConfig(ctx, md); err != nil {
+			return nil, err
+		}
+		c.sendBookmarks(ctx, md)
+		if accessModeFromContext(ctx) == ReadAccess && c.version.major() >= 3 {
+			md["mode"] = "r"
+		}
+	}
+	return md, nil
+}
+
+// run sends a RUN message. md is only sent as of Bolt v3.
+func (c *conn) run(query string, args, md map[string]interface{}) error {
+	return c.encode(c.runMessage(query, args, md))
+}
+
+// runMessage returns the RUN message for the negotiated protocol version.
+func (c *conn) runMessage(query string, args, md map[string]interface{}) messages.Run {
+	if c.version.major() >= 3 {
+		return messages.NewRunMessageWithMetadata(query, args, md)
+	}
+	return messages.NewRunMessage(query, args)
 }
 
 func (c *conn) pullAll() error {
-	return c.encode(messages.NewPullAllMessage())
+	return c.encode(c.pullAllMessage())
+}
+
+// pullAllMessage returns the message that pulls every record for the
+// negotiated protocol version.
+func (c *conn) pullAllMessage() structures.Structure {
+	if c.version.major() >= 4 {
+		return messages.NewPullMessage(-1)
+	}
+	return messages.NewPullAllMessage()
 }
 
-func (c *conn) sendRunPullAll(query string, args map[string]interface{}) error {
-	if err := c.run(query, args); err != nil {
+func (c *conn) sendRunPullAll(query string, args, md map[string]interface{}) error {
+	if err := c.run(query, args, md); err != nil {
 		return err
 	}
 	return c.pullAll()
 }
 
-func (c *conn) sendRunPullAllConsumeRun(query string, args map[string]interface{}) (interface{}, error) {
-	if err := c.sendRunPullAll(query, args); err != nil {
+func (c *conn) sendRunPullAllConsumeRun(query string, args, md map[string]interface{}) (interface{}, error) {
+	if err := c.sendRunPullAll(query, args, md); err != nil {
 		return nil, err
 	}
 	return c.consume()
 }
 
 func (c *conn) sendRunPullAllConsumeSingle(query string, args map[string]interface{}) (interface{}, interface{}, error) {
-	err := c.sendRunPullAll(query, args)
+	err := c.sendRunPullAll(query, args, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1,9 +1,18 @@
 package bolt
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"io"
+	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/sermodigital/bolt/structures/graph"
+	"github.com/sermodigital/bolt/structures/messages"
 )
 
 func newRecorder(t *testing.T, name, dsn string) *sql.DB {
@@ -205,3 +214,703 @@ func TestBoltConn_Ignored(t *testing.T) {
 		t.Fatalf("expected different data from output: %#v", out)
 	}
 }
+
+// v3Handler answers messages the way a Bolt v3 server would, returning a
+// single row with the value 1 for every query.
+func v3Handler(sig uint8, fields []interface{}) []interface{} {
+	success := func(md map[string]interface{}) messages.Success {
+		return messages.Success{Metadata: md}
+	}
+	switch sig {
+	case messages.HelloSignature:
+		return []interface{}{success(map[string]interface{}{"server": "Neo4j/3.5.0"})}
+	case messages.RunSignature:
+		return []interface{}{success(map[string]interface{}{"fields": []interface{}{"1"}})}
+	case messages.PullAllSignature:
+		return []interface{}{
+			messages.Record{Values: []interface{}{int64(1)}},
+			success(map[string]interface{}{"type": "r", "t_last": int64(2)}),
+		}
+	case messages.GoodbyeSignature:
+		return nil
+	default:
+		return []interface{}{success(map[string]interface{}{})}
+	}
+}
+
+func TestBoltConn_V3(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.version = version3_0
+	srv.setHandler(v3Handler)
+
+	db, err := sql.Open(DefaultDriver, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, fn := WithSummary(context.Background())
+	var out int64
+	if err := tx.QueryRowContext(ctx, "RETURN 1").Scan(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out != 1 {
+		t.Fatalf("wanted 1, got %d", out)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if sum := fn(); sum.ServerInfo.Version != "Neo4j/3.5.0" {
+		t.Fatalf("unexpected server version: %q", sum.ServerInfo.Version)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []uint8{
+		messages.HelloSignature,
+		messages.BeginSignature,
+		messages.RunSignature,
+		messages.PullAllSignature,
+		messages.CommitSignature,
+		messages.GoodbyeSignature,
+	}
+	for i := 0; i < 100 && len(srv.received()) < len(want); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if got := srv.received(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("wanted messages %x, got %x", want, got)
+	}
+	if run := srv.messages(messages.RunSignature)[0]; len(run.fields) != 3 {
+		t.Fatalf("wanted RUN to have 3 fields, got %d", len(run.fields))
+	}
+	hello := srv.messages(messages.HelloSignature)[0].fields[0].(map[string]interface{})
+	if hello["user_agent"] != ClientID || hello["scheme"] != "none" {
+		t.Fatalf("unexpected HELLO: %#v", hello)
+	}
+}
+
+func TestBoltConn_V4Database(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.version = version{0x00, 0x00, 0x04, 0x04}
+	srv.setHandler(v3Handler)
+
+	db, err := sql.Open(DefaultDriver, srv.dsn()+"?database=tenant")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	var out int64
+	if err := db.QueryRow("RETURN 1").Scan(&out); err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithDatabase(context.Background(), "other")
+	if err := db.QueryRowContext(ctx, "RETURN 1").Scan(&out); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.QueryRow("RETURN 1").Scan(&out); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	runs := srv.messages(messages.RunSignature)
+	if len(runs) != 3 {
+		t.Fatalf("wanted 3 RUN messages, got %d", len(runs))
+	}
+	for i, want := range []interface{}{"tenant", "other", nil} {
+		md := runs[i].fields[2].(map[string]interface{})
+		if md["db"] != want {
+			t.Fatalf("RUN %d: wanted db %v, got %#v", i, want, md)
+		}
+	}
+	begin := srv.messages(messages.BeginSignature)[0].fields[0].(map[string]interface{})
+	if begin["db"] != "other" {
+		t.Fatalf("wanted BEGIN to select other, got %#v", begin)
+	}
+	pull := srv.messages(messages.PullSignature)[0].fields[0].(map[string]interface{})
+	if pull["n"] != int64(-1) {
+		t.Fatalf("wanted PULL to request all records, got %#v", pull)
+	}
+}
+
+func TestBoltConn_V4Summary(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.version = version{0x00, 0x00, 0x04, 0x04}
+	srv.setHandler(func(sig uint8, fields []interface{}) []interface{} {
+		if sig != messages.PullSignature {
+			return v3Handler(sig, fields)
+		}
+		return []interface{}{
+			messages.Record{Values: []interface{}{int64(1)}},
+			messages.Success{Metadata: map[string]interface{}{
+				"db":     "neo4j",
+				"t_last": int64(7),
+			}},
+		}
+	})
+
+	db, err := sql.Open(DefaultDriver, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// QueryRow closes the rows before reading the response to PULL, and
+	// Query reads it in Next.
+	ctx, sum := WithSummary(context.Background())
+	var out int64
+	if err := db.QueryRowContext(ctx, "RETURN 1").Scan(&out); err != nil {
+		t.Fatal(err)
+	}
+	if s := sum(); s.Database != "neo4j" || s.ConsumedAfter != 7*time.Millisecond {
+		t.Fatalf("wanted database neo4j consumed after 7ms, got %q after %v",
+			s.Database, s.ConsumedAfter)
+	}
+
+	ctx, sum = WithSummary(context.Background())
+	rows, err := db.QueryContext(ctx, "RETURN 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if s := sum(); s.Database != "neo4j" || s.ConsumedAfter != 7*time.Millisecond {
+		t.Fatalf("wanted database neo4j consumed after 7ms, got %q after %v",
+			s.Database, s.ConsumedAfter)
+	}
+}
+
+func TestBoltConn_V4NoRanges(t *testing.T) {
+	// Neo4j prior to 4.3 doesn't understand version ranges.
+	for _, accepts := range [][]version{
+		{{0, 0, 0, 4}, version3_0},
+		{{0, 0, 1, 4}, {0, 0, 0, 4}, version3_0},
+	} {
+		srv := newStubServer(t)
+		srv.accepts = accepts
+		srv.setHandler(v3Handler)
+
+		c, err := Open(srv.dsn() + "?database=tenant")
+		if err != nil {
+			t.Fatalf("%v: %v", accepts, err)
+		}
+		if v := c.(*conn).version; v != (version{0, 0, 0, 4}) {
+			t.Fatalf("%v: wanted to negotiate 4.0, got %v", accepts, v)
+		}
+		c.Close()
+		srv.Close()
+	}
+}
+
+func TestBoltConn_DatabaseUnsupported(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.version = version3_0
+	srv.setHandler(v3Handler)
+
+	_, err := Open(srv.dsn() + "?database=tenant")
+	if err != ErrDatabaseUnsupported {
+		t.Fatalf("wanted ErrDatabaseUnsupported, got %v", err)
+	}
+}
+
+func TestBoltConn_V5ElementIDs(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.version = version{0x00, 0x00, 0x00, 0x05}
+
+	node := graph.Node{
+		NodeIdentity: 1,
+		Labels:       []string{"FOO"},
+		Properties:   map[string]interface{}{"a": int64(1)},
+		ElementID:    "4:db:1",
+	}
+	rel := graph.Relationship{
+		RelIdentity:        2,
+		StartNodeIdentity:  1,
+		EndNodeIdentity:    1,
+		Type:               "SELF",
+		Properties:         map[string]interface{}{},
+		ElementID:          "5:db:2",
+		StartNodeElementID: "4:db:1",
+		EndNodeElementID:   "4:db:1",
+	}
+	path := graph.Path{
+		Nodes: []graph.Node{node},
+		Relationships: []graph.UnboundRelationship{{
+			RelIdentity: 2,
+			Type:        "SELF",
+			Properties:  map[string]interface{}{},
+			ElementID:   "5:db:2",
+		}},
+		Sequence: []int{1, 0},
+	}
+	srv.setHandler(func(sig uint8, fields []interface{}) []interface{} {
+		switch sig {
+		case messages.RunSignature:
+			return []interface{}{messages.Success{Metadata: map[string]interface{}{
+				"fields": []interface{}{"n", "r", "p"},
+			}}}
+		case messages.PullSignature:
+			return []interface{}{
+				messages.Record{Values: []interface{}{node, rel, path}},
+				messages.Success{Metadata: map[string]interface{}{}},
+			}
+		}
+		return v3Handler(sig, fields)
+	})
+
+	db, err := sql.Open(DefaultDriver, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var (
+		n graph.Node
+		r graph.Relationship
+		p graph.Path
+	)
+	if err := db.QueryRow("MATCH p = (n)-[r]->(n) RETURN n, r, p").Scan(&n, &r, &p); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(n, node) {
+		t.Fatalf("wanted %#v, got %#v", node, n)
+	}
+	if !reflect.DeepEqual(r, rel) {
+		t.Fatalf("wanted %#v, got %#v", rel, r)
+	}
+	if !reflect.DeepEqual(p, path) {
+		t.Fatalf("wanted %#v, got %#v", path, p)
+	}
+}
+
+func TestBoltConn_Temporal(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	zoned := time.Date(2018, time.March, 25, 3, 30, 0, 5, berlin)
+	// EST's name is the same as its abbreviation, like a fixed zone's.
+	est, err := time.LoadLocation("EST")
+	if err != nil {
+		t.Skip(err)
+	}
+	abbrev := time.Date(2018, time.March, 25, 3, 30, 0, 5, est)
+	// Fixed zones and the zones time.Parse makes up for abbreviations have
+	// zone IDs for names, but not their offsets.
+	cet := time.Date(2020, time.July, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	mst, err := time.Parse(time.RFC1123, "Wed, 01 Jul 2020 12:00:00 MST")
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset := time.Date(2018, time.March, 25, 3, 30, 0, 5, time.FixedZone("", -5*60*60))
+	date := graph.NewDate(1969, time.July, 20)
+	ltime := graph.NewLocalTime(20, 17, 40, 1)
+	otime := graph.NewTime(20, 17, 40, 1, 3600)
+	ldt := graph.LocalDateTimeOf(zoned)
+	dur := graph.Duration{Months: 14, Days: 3, Seconds: 7, Nanos: 9}
+
+	for _, vers := range []version{version{0, 0, 4, 4}, version{0, 0, 0, 5}} {
+		srv := newStubServer(t)
+		srv.version = vers
+		srv.setHandler(echoHandler)
+
+		db, err := sql.Open(DefaultDriver, srv.dsn())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var (
+			a, b, i, j, k time.Time
+			c             graph.Date
+			d             graph.LocalTime
+			e             graph.Time
+			f             graph.LocalDateTime
+			g, h          graph.Duration
+		)
+		err = db.QueryRow("RETURN $a, $b, $c, $d, $e, $f, $g, $h, $i, $j, $k",
+			sql.Named("a", zoned), sql.Named("b", offset), sql.Named("c", date),
+			sql.Named("d", ltime), sql.Named("e", otime), sql.Named("f", ldt),
+			sql.Named("g", dur), sql.Named("h", 90*time.Second+time.Nanosecond),
+			sql.Named("i", abbrev), sql.Named("j", cet), sql.Named("k", mst),
+		).Scan(&a, &b, &c, &d, &e, &f, &g, &h, &i, &j, &k)
+		if err != nil {
+			t.Fatalf("%v: %v", vers, err)
+		}
+
+		if !a.Equal(zoned) || a.Location().String() != "Europe/Berlin" {
+			t.Fatalf("%v: wanted %v, got %v", vers, zoned, a)
+		}
+		if !i.Equal(abbrev) || i.Location().String() != "EST" {
+			t.Fatalf("%v: wanted %v in zone EST, got %v", vers, abbrev, i)
+		}
+		for _, tt := range []struct{ got, want time.Time }{{j, cet}, {k, mst}} {
+			_, got := tt.got.Zone()
+			_, want := tt.want.Zone()
+			if !tt.got.Equal(tt.want) || got != want {
+				t.Fatalf("%v: wanted %v, got %v", vers, tt.want, tt.got)
+			}
+		}
+		if !b.Equal(offset) {
+			t.Fatalf("%v: wanted %v, got %v", vers, offset, b)
+		}
+		if _, off := b.Zone(); off != -5*60*60 {
+			t.Fatalf("%v: wanted offset -5h, got %d", vers, off)
+		}
+		if c != date || d != ltime || f != ldt || g != dur {
+			t.Fatalf("%v: unexpected local values: %v %v %v %v", vers, c, d, f, g)
+		}
+		if e.Nanoseconds() != otime.Nanoseconds() || e.Offset() != 3600 {
+			t.Fatalf("%v: wanted %v, got %v", vers, otime, e)
+		}
+		if h.Duration() != 90*time.Second+time.Nanosecond {
+			t.Fatalf("%v: wanted 90s, got %v", vers, h)
+		}
+
+		if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+		srv.Close()
+	}
+}
+
+func TestBoltConn_Spatial(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.setHandler(echoHandler)
+
+	db, err := sql.Open(DefaultDriver, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	p2 := graph.Point2D{SRID: 4326, X: 13.4, Y: 52.5}
+	p3 := graph.Point3D{SRID: 4979, X: 13.4, Y: 52.5, Z: 34}
+	var (
+		a graph.Point2D
+		b graph.Point3D
+	)
+	err = db.QueryRow("RETURN $a, $b", sql.Named("a", p2), sql.Named("b", p3)).Scan(&a, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != p2 || b != p3 {
+		t.Fatalf("wanted %v and %v, got %v and %v", p2, p3, a, b)
+	}
+}
+
+func TestBoltConn_Bytes(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.setHandler(echoHandler)
+
+	db, err := sql.Open(DefaultDriver, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, n := range []int{0, 32, 255, 256, 70000} {
+		in := make([]byte, n)
+		for i := range in {
+			in[i] = byte(i)
+		}
+		var out []byte
+		if err := db.QueryRow("RETURN $b", Map{"b": in}).Scan(&out); err != nil {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+		if !bytes.Equal(in, out) {
+			t.Fatalf("%d bytes: wanted %x, got %x", n, in, out)
+		}
+	}
+
+	// Lengths are unsigned, so these must not be mistaken for negative
+	// sizes.
+	for _, n := range []int{200, 40000} {
+		in := strings.Repeat("x", n)
+		var out string
+		if err := db.QueryRow("RETURN $s", Map{"s": in}).Scan(&out); err != nil {
+			t.Fatalf("%d byte string: %v", n, err)
+		}
+		if in != out {
+			t.Fatalf("%d byte string: got %d bytes back", n, len(out))
+		}
+	}
+}
+
+func TestBoltConn_Reflect(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.setHandler(echoHandler)
+
+	db, err := sql.Open(DefaultDriver, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	type Base struct {
+		ID   int64  `bolt:"id"`
+		Name string `bolt:"base_name"`
+	}
+	type person struct {
+		Base
+		Name    string            `bolt:"name"`
+		Email   string            `bolt:"email,omitempty"`
+		Tags    []string          `bolt:"tags"`
+		Labels  map[string]string `bolt:"labels"`
+		Ignored string            `bolt:"-"`
+		Age     uint8
+		secret  string
+	}
+
+	tests := []struct {
+		in   interface{}
+		want interface{}
+	}{
+		{[]string{"a", "b"}, []interface{}{"a", "b"}},
+		{[]int64{1, 2, 3}, []interface{}{int64(1), int64(2), int64(3)}},
+		{[2]float64{1.5, 2.5}, []interface{}{1.5, 2.5}},
+		{map[string]string{"k": "v"}, map[string]interface{}{"k": "v"}},
+		{
+			person{
+				Base:    Base{ID: 1, Name: "base"},
+				Name:    "Alice",
+				Tags:    []string{"x"},
+				Labels:  map[string]string{"team": "graph"},
+				Ignored: "ignored",
+				Age:     30,
+				secret:  "secret",
+			},
+			map[string]interface{}{
+				"id":        int64(1),
+				"base_name": "base",
+				"name":      "Alice",
+				"tags":      []interface{}{"x"},
+				"labels":    map[string]interface{}{"team": "graph"},
+				"Age":       int64(30),
+			},
+		},
+		{(*person)(nil), nil},
+	}
+	for i, tt := range tests {
+		var out interface{}
+		if err := db.QueryRow("RETURN $v", Map{"v": tt.in}).Scan(&out); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if !reflect.DeepEqual(out, tt.want) {
+			t.Fatalf("#%d: wanted %#v, got %#v", i, tt.want, out)
+		}
+	}
+
+	_, err = db.Exec("RETURN $v", Map{"v": map[int]string{1: "a"}})
+	if err == nil {
+		t.Fatal("wanted an error encoding a map with non-string keys")
+	}
+}
+
+// stallHandler answers RUN but never finishes a PULL_ALL, simulating a
+// long-running query. RESET aborts the query if reset is true.
+func stallHandler(reset bool) func(uint8, []interface{}) []interface{} {
+	var stalled int32
+	return func(sig uint8, fields []interface{}) []interface{} {
+		switch sig {
+		case messages.RunSignature:
+			return []interface{}{messages.Success{Metadata: map[string]interface{}{
+				"fields": []interface{}{"n"},
+			}}}
+		case messages.PullAllSignature:
+			atomic.StoreInt32(&stalled, 1)
+			return nil
+		case messages.ResetSignature:
+			if !reset {
+				return nil
+			}
+			resp := []interface{}{messages.Success{Metadata: map[string]interface{}{}}}
+			if atomic.SwapInt32(&stalled, 0) == 1 {
+				resp = append([]interface{}{messages.Ignored{}}, resp...)
+			}
+			return resp
+		default:
+			return []interface{}{messages.Success{Metadata: map[string]interface{}{}}}
+		}
+	}
+}
+
+func TestBoltConn_Cancel(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.setHandler(stallHandler(true))
+
+	db, err := sql.Open(DefaultDriver, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := db.ExecContext(ctx, "RETURN 1"); err != context.DeadlineExceeded {
+		t.Fatalf("wanted context.DeadlineExceeded, got %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	rows, err := db.QueryContext(ctx, "RETURN 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if rows.Next() {
+		t.Fatal("wanted no rows")
+	}
+	if err := rows.Err(); err != context.Canceled {
+		t.Fatalf("wanted context.Canceled, got %v", err)
+	}
+	rows.Close()
+
+	if _, err := db.QueryContext(ctx, "RETURN 1"); err != context.Canceled {
+		t.Fatalf("wanted context.Canceled, got %v", err)
+	}
+
+	srv.setHandler(echoHandler)
+	var n int64
+	if err := db.QueryRow("RETURN $n", Map{"n": 1}).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.mu.Lock()
+	conns := srv.conns
+	srv.mu.Unlock()
+	if conns != 1 {
+		t.Fatalf("wanted the connection to be reused, got %d dials", conns)
+	}
+	// Two RESETs interrupted the queries and two reset the session before
+	// the connection was reused.
+	if resets := len(srv.messages(messages.ResetSignature)); resets != 4 {
+		t.Fatalf("wanted 4 RESETs, got %d", resets)
+	}
+}
+
+func TestBoltConn_CancelUnresponsive(t *testing.T) {
+	defer func(d time.Duration) { interruptTimeout = d }(interruptTimeout)
+	interruptTimeout = 50 * time.Millisecond
+
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.setHandler(stallHandler(false))
+
+	db, err := sql.Open(DefaultDriver, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := db.ExecContext(ctx, "RETURN 1"); err != context.DeadlineExceeded {
+		t.Fatalf("wanted context.DeadlineExceeded, got %v", err)
+	}
+
+	srv.setHandler(echoHandler)
+	var n int64
+	if err := db.QueryRow("RETURN $n", Map{"n": 1}).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.mu.Lock()
+	conns := srv.conns
+	srv.mu.Unlock()
+	if conns != 2 {
+		t.Fatalf("wanted the connection to be replaced, got %d dials", conns)
+	}
+}
+
+func TestBoltConn_Neo(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.setHandler(echoHandler)
+
+	c, err := OpenNeo(srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	node := graph.Node{NodeIdentity: 1, Labels: []string{"Person"}, Properties: map[string]interface{}{}}
+	params := map[string]interface{}{"n": node, "xs": []interface{}{int64(1)}}
+	rows, err := c.QueryNeo("RETURN $n, $xs", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cols := rows.Columns(); !reflect.DeepEqual(cols, []string{"n", "xs"}) {
+		t.Fatalf("wanted columns n and xs, got %v", cols)
+	}
+	row, _, err := rows.NextNeo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{node, []interface{}{int64(1)}}; !reflect.DeepEqual(row, want) {
+		t.Fatalf("wanted %#v, got %#v", want, row)
+	}
+	if _, md, err := rows.NextNeo(); err != io.EOF || md == nil {
+		t.Fatalf("wanted metadata and io.EOF, got %v and %v", md, err)
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, runmd, _, err := c.QueryNeoAll("RETURN $n", map[string]interface{}{"n": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]interface{}{{"x"}}; !reflect.DeepEqual(data, want) {
+		t.Fatalf("wanted %v, got %v", want, data)
+	}
+	if _, ok := runmd["fields"]; !ok {
+		t.Fatalf("wanted RUN metadata, got %v", runmd)
+	}
+
+	stmt, err := c.PrepareNeo("RETURN $n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+	if _, err := stmt.ExecNeo(map[string]interface{}{"n": int64(1)}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err = stmt.QueryNeo(map[string]interface{}{"n": int64(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _, err = rows.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]interface{}{{int64(2)}}; !reflect.DeepEqual(data, want) {
+		t.Fatalf("wanted %v, got %v", want, data)
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
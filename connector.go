@@ -0,0 +1,208 @@
+package bolt
+
+import (
+	"context"
+	"database/sql/driver"
+	"net"
+	"time"
+)
+
+// Config is the configuration used to open connections. OpenConnector parses
+// it from a connection URI and environment variables; see the package
+// documentation for the format.
+type Config struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+
+	// Auth, if not nil, is sent to authenticate instead of Username and
+	// Password, e.g. to use a bearer token or a Kerberos ticket.
+	Auth *AuthToken
+
+	// Database is the database to run queries against. It requires Bolt v4
+	// or later.
+	Database string
+
+	// Timeout is the read and write timeout. Zero means no timeout.
+	Timeout time.Duration
+
+	// DialTimeout is the timeout for dialing a new connection. Zero means no
+	// timeout, although the deadline of the context passed to Connect is
+	// always honored.
+	DialTimeout time.Duration
+}
+
+// parseDSN merges the default configuration, environment variables, and the
+// parameters of the connection URI name, which take precedence.
+func parseDSN(name string) (values, error) {
+	// Default Neo4j configuration information.
+	v := values{"host": DefaultHost, "port": DefaultPort}
+
+	// Parse environment variables if applicable. These will be overwritten by
+	// the URI configuration if it exists.
+	v.merge(parseEnv())
+
+	// Parse our values from the URL if applicable.
+	if hasScheme(name) {
+		if err := parseURL(v, name); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// newConfig returns the Config described by v.
+func newConfig(v values) (Config, error) {
+	cfg := Config{
+		Host:     v.get("host"),
+		Port:     v.get("port"),
+		Username: v.get("username"),
+		Password: v.get("password"),
+		Database: v.get("database"),
+	}
+	var err error
+	if cfg.Auth, err = parseAuth(v); err != nil {
+		return Config{}, err
+	}
+	if cfg.Timeout, err = parseTimeout(v.get("timeout")); err != nil {
+		return Config{}, err
+	}
+	if cfg.DialTimeout, err = parseTimeout(v.get("dial_timeout")); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Connector opens connections with a fixed Config and Dialer. It implements
+// driver.Connector and can be passed to sql.OpenDB.
+type Connector struct {
+	cfg    Config
+	dialer Dialer
+}
+
+var _ driver.Connector = (*Connector)(nil)
+
+// NewConnector returns a Connector that opens connections using cfg and d. If
+// d is nil the default, non-TLS, Dialer is used. An empty host or port is
+// replaced by DefaultHost or DefaultPort.
+func NewConnector(cfg Config, d Dialer) *Connector {
+	if cfg.Host == "" {
+		cfg.Host = DefaultHost
+	}
+	if cfg.Port == "" {
+		cfg.Port = DefaultPort
+	}
+	if d == nil {
+		d = &dialer{}
+	}
+	return &Connector{cfg: cfg, dialer: d}
+}
+
+// OpenConnector parses name once and returns a Connector, or a
+// RoutingConnector if name has one of the routing schemes, that uses it for
+// every connection. It helps implement driver.DriverContext.
+func (d *drv) OpenConnector(name string) (driver.Connector, error) {
+	return newConnector(nil, name)
+}
+
+// newConnector parses name and returns the driver.Connector for it. If d is
+// nil the Dialer is configured by the tls parameters.
+func newConnector(d Dialer, name string) (driver.Connector, error) {
+	v, err := parseDSN(name)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := newConfig(v)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		if d, err = newDialer(v); err != nil {
+			return nil, err
+		}
+	}
+	if isRouting(name) {
+		return NewRoutingConnector(cfg, d), nil
+	}
+	return NewConnector(cfg, d), nil
+}
+
+// Connect opens a new connection. If ctx is done before the connection is
+// established the connection is closed and ctx.Err() is returned. It
+// implements driver.Connector.
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	nc, err := dial(ctx, c.dialer, c.cfg)
+	if err != nil {
+		if cerr := ctx.Err(); cerr != nil {
+			return nil, cerr
+		}
+		return nil, err
+	}
+	if ctx.Done() == nil {
+		conn, err := newConn(nc, c.cfg)
+		if err != nil {
+			return nil, err
+		}
+		return conn, nil
+	}
+
+	// Close the connection if ctx is done during the handshake, interrupting
+	// any blocked reads or writes.
+	var (
+		stop     = make(chan struct{})
+		canceled = make(chan bool, 1)
+	)
+	go func() {
+		select {
+		case <-stop:
+			canceled <- false
+		case <-ctx.Done():
+			nc.Close()
+			canceled <- true
+		}
+	}()
+	conn, err := newConn(nc, c.cfg)
+	close(stop)
+	if <-canceled {
+		if err == nil {
+			conn.Close()
+		}
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Driver implements driver.Connector.
+func (c *Connector) Driver() driver.Driver {
+	return &drv{}
+}
+
+// ContextDialer is implemented by Dialers that can dial with a context. The
+// Dialers returned by TLSDialer implement it. Dialers that don't are given
+// the time remaining until the context's deadline as their timeout.
+type ContextDialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+func dial(ctx context.Context, d Dialer, cfg Config) (net.Conn, error) {
+	addr := net.JoinHostPort(cfg.Host, cfg.Port)
+	if cfg.DialTimeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.DialTimeout)
+		defer cancel()
+	}
+	if cd, ok := d.(ContextDialer); ok {
+		return cd.DialContext(ctx, "tcp", addr)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		return d.DialTimeout("tcp", addr, time.Until(deadline))
+	}
+	return d.Dial("tcp", addr)
+}
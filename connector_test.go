@@ -0,0 +1,89 @@
+package bolt
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sermodigital/bolt/structures/messages"
+)
+
+func TestConnector(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.setHandler(echoHandler)
+
+	host, port, err := net.SplitHostPort(srv.ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{Host: host, Port: port, Username: "john", Password: "hunter2"}
+	db := sql.OpenDB(NewConnector(cfg, nil))
+	defer db.Close()
+
+	var n int64
+	if err := db.QueryRow("RETURN $n", Map{"n": 1}).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("wanted 1, got %d", n)
+	}
+
+	init := srv.messages(messages.InitSignature)
+	if len(init) != 1 {
+		t.Fatalf("wanted 1 INIT, got %d", len(init))
+	}
+	auth, _ := init[0].fields[1].(map[string]interface{})
+	if auth["principal"] != "john" || auth["credentials"] != "hunter2" {
+		t.Fatalf("wrong credentials: %v", auth)
+	}
+}
+
+func TestConnector_OpenConnector(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+
+	c, err := (&drv{}).OpenConnector(srv.dsn() + "?timeout=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, ok := c.(*Connector)
+	if !ok {
+		t.Fatalf("wanted *Connector, got %T", c)
+	}
+	if conn.cfg.Timeout != 3*time.Second {
+		t.Fatalf("wanted a 3s timeout, got %s", conn.cfg.Timeout)
+	}
+
+	db, err := sql.Open(DefaultDriver, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConnector_Cancel(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.setHandler(func(sig uint8, fields []interface{}) []interface{} {
+		// Never answer INIT.
+		return nil
+	})
+
+	host, port, err := net.SplitHostPort(srv.ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewConnector(Config{Host: host, Port: port}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.Connect(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("wanted context.DeadlineExceeded, got %v", err)
+	}
+}
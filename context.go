@@ -0,0 +1,76 @@
+package bolt
+
+import (
+	"context"
+	"time"
+)
+
+// databaseKey is used to access the name of the database a query should run
+// against.
+type databaseKey struct{}
+
+// WithDatabase returns a context.Context that causes queries and transactions
+// started with it to run against the named database instead of the one given
+// by the connection's database parameter. Selecting a database requires Bolt
+// v4 or later.
+func WithDatabase(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, databaseKey{}, name)
+}
+
+// databaseFromContext returns the database selected by ctx, or def if ctx
+// does not select one.
+func databaseFromContext(ctx context.Context, def string) string {
+	if name, ok := ctx.Value(databaseKey{}).(string); ok {
+		return name
+	}
+	return def
+}
+
+// txTimeoutKey and txMetadataKey are used to access the configuration of the
+// transactions started with a context.
+type (
+	txTimeoutKey  struct{}
+	txMetadataKey struct{}
+)
+
+// WithTxTimeout returns a context.Context that causes transactions started
+// with it, including the implicit transactions of queries run outside of a
+// transaction, to be terminated by the server if they run longer than d.
+// It requires Bolt v3 or later.
+func WithTxTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, txTimeoutKey{}, d)
+}
+
+// WithTxMetadata returns a context.Context that attaches md to transactions
+// started with it, including the implicit transactions of queries run outside
+// of a transaction. The metadata is visible in the server's query log and
+// the output of dbms.listTransactions. It requires Bolt v3 or later.
+func WithTxMetadata(ctx context.Context, md map[string]interface{}) context.Context {
+	return context.WithValue(ctx, txMetadataKey{}, md)
+}
+
+// AccessMode is the kind of work done by a transaction. Clusters route read
+// transactions to their followers and write transactions to their leader.
+type AccessMode uint8
+
+const (
+	WriteAccess AccessMode = iota // the default
+	ReadAccess
+)
+
+// accessModeKey is used to access the AccessMode of a context.
+type accessModeKey struct{}
+
+// WithAccessMode returns a context.Context that causes queries run outside of
+// a transaction and transactions started with it to use mode. A transaction
+// started with sql.TxOptions.ReadOnly always uses ReadAccess.
+func WithAccessMode(ctx context.Context, mode AccessMode) context.Context {
+	return context.WithValue(ctx, accessModeKey{}, mode)
+}
+
+// accessModeFromContext returns the AccessMode selected by ctx, or
+// WriteAccess if ctx does not select one.
+func accessModeFromContext(ctx context.Context) AccessMode {
+	mode, _ := ctx.Value(accessModeKey{}).(AccessMode)
+	return mode
+}
@@ -28,15 +28,30 @@
 //
 //	bolt://[user[:password]]@[host][:port][?param1=value1&...]
 //
+// The neo4j:// scheme connects to a cluster through the member at host and
+// port. See RoutingConnector. The bolt+s:// and neo4j+s:// schemes use TLS and
+// verify the server's certificate, while bolt+ssc:// and neo4j+ssc:// use TLS
+// but accept self-signed certificates. Neither can be combined with the tls
+// and tls_no_verify parameters.
+//
 // Parameters are as follows:
 //
 //	- dial_timeout:     Timeout for dialing a new connection in seconds.
 //	- timeout:          Read and write timeout in seconds.
+//	- database:         Database to run queries against (Bolt v4 and later).
+//	- auth_scheme:      basic, none, bearer, kerberos or a custom scheme.
+//	- auth_credentials: Bearer token, Kerberos ticket or custom credentials.
+//	- auth_realm:       Realm the credentials are checked against.
+//	- auth_param_*:     Parameters sent to a custom scheme.
 //	- tls:              Should the connection use TLS? 1 or 0.
 //	- tls_ca_cert_file: Path to CA certificate file.
 //	- tls_cert_file:    Path to certificate file.
 //	- tls_key_file:     Path to key file.
 //	- tls_no_verify:    Should the connection _not_ verify TLS? 1 or 0.
+//	- tls_server_name:  Server name sent with SNI and used for verification.
+//	- tls_min_version:  Minimum TLS version, 1.2 (the default) or 1.3.
+//	- tls_pins:         Comma separated SHA-256 certificate fingerprints.
+//	- tls_reload:       How often the TLS files are re-read in seconds.
 //
 // Eenvironment variables can be used, although URI parameters will take
 // precedence over envirnment variables. In the same order as above:
@@ -1,12 +1,12 @@
 package bolt
 
 import (
+	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
-	"io/ioutil"
+	"fmt"
 	"net"
 	"net/url"
 	"os"
@@ -19,17 +19,48 @@ var handshake = [...]byte{
 	// magic preamble
 	0x60, 0x60, 0xB0, 0x17,
 
-	// supported versions
-	0x00, 0x00, 0x00, 0x01,
-	0x00, 0x00, 0x00, 0x00,
-	0x00, 0x00, 0x00, 0x00,
-	0x00, 0x00, 0x00, 0x00,
+	// supported versions, in order of preference. Each is encoded as
+	// [unused, range, minor, major] where range is the number of
+	// consecutive minor versions below minor that are also supported.
+	// Servers prior to 4.3 ignore ranges, so 4.0, which every 4.x server
+	// speaks, is also offered on its own. There's no slot left for Bolt v1.
+	0x00, 0x00, 0x00, 0x05,
+	0x00, 0x04, 0x04, 0x04, // 4.4 through 4.0
+	0x00, 0x00, 0x00, 0x04,
+	0x00, 0x00, 0x00, 0x03,
 }
 
 type version [4]byte
 
 var noSupportedVersions = version{0x00, 0x00, 0x00, 0x00}
 var version1_0 = version{0x00, 0x00, 0x00, 0x01}
+var version3_0 = version{0x00, 0x00, 0x00, 0x03}
+
+// major returns the major protocol version.
+func (v version) major() uint8 {
+	return v[3]
+}
+
+// minor returns the minor protocol version.
+func (v version) minor() uint8 {
+	return v[2]
+}
+
+// supported reports whether v is a protocol version the driver implements.
+func (v version) supported() bool {
+	switch v.major() {
+	case 1, 3, 5:
+		return v.minor() == 0
+	case 4:
+		return v.minor() <= 4
+	default:
+		return false
+	}
+}
+
+func (v version) String() string {
+	return fmt.Sprintf("%d.%d", v.major(), v.minor())
+}
 
 const (
 	// Version is the current version of this driver
@@ -41,33 +72,81 @@ const (
 	DefaultPort = "7687"      // default port for regular socket connections
 	DefaultHost = "localhost" // default host for regular socket connections
 	Scheme      = "bolt://"   // Bolt protocol's URI scheme
+
+	// RoutingScheme is the URI scheme of Neo4j clusters. See
+	// RoutingConnector.
+	RoutingScheme = "neo4j://"
+
+	// The +s schemes use TLS and verify the server's certificate against
+	// the system's CAs, or the tls_ca_cert_file parameter. The +ssc schemes
+	// use TLS but accept any certificate, including self-signed ones.
+	SecureScheme            = "bolt+s://"
+	SelfSignedScheme        = "bolt+ssc://"
+	SecureRoutingScheme     = "neo4j+s://"
+	SelfSignedRoutingScheme = "neo4j+ssc://"
 )
 
-// Open calls DialOpen with the default dialer.
+// scheme describes a URI scheme.
+type scheme struct {
+	routing  bool   // connects to a cluster
+	tls      bool   // requires TLS
+	noVerify string // value of the tls_no_verify parameter if tls is set
+}
+
+// schemes are the supported URI schemes, without "://".
+var schemes = map[string]scheme{
+	"bolt":      {},
+	"bolt+s":    {tls: true, noVerify: "0"},
+	"bolt+ssc":  {tls: true, noVerify: "1"},
+	"neo4j":     {routing: true},
+	"neo4j+s":   {routing: true, tls: true, noVerify: "0"},
+	"neo4j+ssc": {routing: true, tls: true, noVerify: "1"},
+}
+
+// hasScheme reports whether name is a URI, i.e. has a scheme.
+func hasScheme(name string) bool {
+	return strings.Contains(name, "://")
+}
+
+// isRouting reports whether name has one of the routing schemes.
+func isRouting(name string) bool {
+	i := strings.Index(name, "://")
+	return i >= 0 && schemes[name[:i]].routing
+}
+
+// Open calls DialOpen with a nil Dialer.
 func Open(name string) (driver.Conn, error) {
-	switch os.Getenv(TLSEnv) {
-	case "1", "true":
-		drv, err := TLSDialer("", "", "", false)
-		if err != nil {
-			return nil, err
-		}
-		return DialOpen(drv, name)
-	default:
-		return DialOpen(&dialer{}, name)
-	}
+	return DialOpen(nil, name)
 }
 
 // DialOpen opens a driver.Conn with the given Dialer and network configuration.
+// If d is nil the connection is dialed with the Dialer configured by the tls
+// parameters of name and the corresponding environment variables.
 func DialOpen(d Dialer, name string) (driver.Conn, error) {
-	nc, v, err := open(d, name)
+	c, err := newConnector(d, name)
 	if err != nil {
 		return nil, err
 	}
-	conn, err := newConn(nc, v)
+	return c.Connect(context.Background())
+}
+
+// OpenNeo is like Open but returns a Conn. It doesn't accept the routing
+// schemes.
+func OpenNeo(name string) (Conn, error) {
+	return DialOpenNeo(nil, name)
+}
+
+// DialOpenNeo is like DialOpen but returns a Conn. It doesn't accept the
+// routing schemes.
+func DialOpenNeo(d Dialer, name string) (Conn, error) {
+	if isRouting(name) {
+		return nil, ErrRoutingUnsupported
+	}
+	c, err := DialOpen(d, name)
 	if err != nil {
 		return nil, err
 	}
-	return conn, nil
+	return c.(Conn), nil
 }
 
 // parseTimeout returns the timeout in seconds.
@@ -82,41 +161,6 @@ func parseTimeout(tos string) (time.Duration, error) {
 	return time.Duration(timeout) * time.Second, nil
 }
 
-func open(d Dialer, name string) (net.Conn, values, error) {
-	// Default Neo4j configuration information.
-	v := values{"host": DefaultHost, "port": DefaultPort}
-
-	// Parse environment variables if applicable. These will be overwritten by
-	// the URI configuration if it exists.
-	v.merge(parseEnv())
-
-	// Parse our values from the URL if applicable.
-	if strings.HasPrefix(name, Scheme) {
-		err := parseURL(v, name)
-		if err != nil {
-			return nil, nil, err
-		}
-	}
-
-	conn, err := dial(d, v)
-	if err != nil {
-		return nil, nil, err
-	}
-	return conn, v, nil
-}
-
-func dial(d Dialer, v values) (net.Conn, error) {
-	addr := net.JoinHostPort(v.get("host"), v.get("port"))
-	timeout, err := parseTimeout(v.get("dial_timeout"))
-	if err != nil {
-		return nil, err
-	}
-	if timeout != 0 {
-		return d.DialTimeout("tcp", addr, timeout)
-	}
-	return d.Dial("tcp", addr)
-}
-
 const (
 	// HostEnv is the environment variable read to gather the host information.
 	HostEnv = "BOLT_DRIVER_HOST"
@@ -164,6 +208,16 @@ func parseEnv() values {
 			v.set("user", p)
 		case PassEnv:
 			v.set("password", p)
+		case TLSEnv:
+			v.set("tls", p)
+		case TLSNoVerifyEnv:
+			v.set("tls_no_verify", p)
+		case TLSCACertFileEnv:
+			v.set("tls_ca_cert_file", p)
+		case TLSCertFileEnv:
+			v.set("tls_cert_file", p)
+		case TLSKeyFileEnv:
+			v.set("tls_key_file", p)
 		}
 	}
 	return v
@@ -174,6 +228,10 @@ func parseURL(v values, name string) error {
 	if err != nil {
 		return err
 	}
+	sch, ok := schemes[url.Scheme]
+	if !ok {
+		return fmt.Errorf("bolt: unsupported URI scheme: %q", url.Scheme)
+	}
 	host, port, err := net.SplitHostPort(url.Host)
 	if err != nil {
 		return err
@@ -190,14 +248,40 @@ func parseURL(v values, name string) error {
 	}
 	m := url.Query()
 	set := func(key string) {
-		v.set(key, m.Get(key))
+		// Parameters that are absent don't override environment variables.
+		if _, ok := m[key]; ok {
+			v.set(key, m.Get(key))
+		}
 	}
 	set("timeout")
+	set("dial_timeout")
+	set("database")
+	set("auth_scheme")
+	set("auth_credentials")
+	set("auth_realm")
+	for key := range m {
+		if strings.HasPrefix(key, authParamPrefix) {
+			set(key)
+		}
+	}
 	set("tls")
 	set("tls_ca_cert_file")
 	set("tls_cert_file")
 	set("tls_key_file")
 	set("tls_no_verify")
+	set("tls_server_name")
+	set("tls_min_version")
+	set("tls_pins")
+	set("tls_reload")
+	if sch.tls {
+		for _, key := range [...]string{"tls", "tls_no_verify"} {
+			if _, ok := m[key]; ok {
+				return fmt.Errorf("bolt: the %s parameter can't be used with the %s scheme", key, url.Scheme)
+			}
+		}
+		v.set("tls", "1")
+		v.set("tls_no_verify", sch.noVerify)
+	}
 	return nil
 }
 
@@ -215,6 +299,7 @@ type Dialer interface {
 // to DialOpen and DialOpenNeo. It reads configuration information from
 // environment variables, although the function parameters take precedence.
 // noVerify will only be read from an environment variable if noVerify is false.
+// See NewTLSDialer for more options.
 func TLSDialer(caFile, certFile, keyFile string, noVerify bool) (Dialer, error) {
 	if caFile == "" {
 		caFile = os.Getenv(TLSCACertFileEnv)
@@ -225,63 +310,104 @@ func TLSDialer(caFile, certFile, keyFile string, noVerify bool) (Dialer, error)
 	if keyFile == "" {
 		keyFile = os.Getenv(TLSKeyFileEnv)
 	}
-
-	cfg := &tls.Config{MinVersion: tls.VersionTLS10, MaxVersion: tls.VersionTLS12}
-
-	if caFile != "" {
-		cert, err := ioutil.ReadFile(caFile)
-		if err != nil {
-			return nil, err
-		}
-		pool := x509.NewCertPool()
-		if !pool.AppendCertsFromPEM(cert) {
-			return nil, errors.New("could not append CA certificate")
-		}
-		cfg.RootCAs = pool
+	if !noVerify {
+		noVerify = os.Getenv(TLSNoVerifyEnv) == "1"
 	}
+	return NewTLSDialer(TLSOptions{
+		CACertFile: caFile,
+		CertFile:   certFile,
+		KeyFile:    keyFile,
+		NoVerify:   noVerify,
+	})
+}
 
-	if certFile != "" {
-		if keyFile == "" {
-			return nil, errors.New("cert file requires a key file")
-		}
-		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
-		if err != nil {
-			return nil, err
-		}
-		cfg.Certificates = []tls.Certificate{cert}
+// newDialer returns the Dialer configured by the tls parameters in v.
+func newDialer(v values) (Dialer, error) {
+	useTLS, err := v.bool("tls")
+	if err != nil {
+		return nil, err
 	}
-
-	if !noVerify {
-		noVerify = os.Getenv(TLSNoVerifyEnv) == "1"
+	if !useTLS {
+		return &dialer{}, nil
+	}
+	opts := TLSOptions{
+		CACertFile: v.get("tls_ca_cert_file"),
+		CertFile:   v.get("tls_cert_file"),
+		KeyFile:    v.get("tls_key_file"),
+		ServerName: v.get("tls_server_name"),
+	}
+	if opts.NoVerify, err = v.bool("tls_no_verify"); err != nil {
+		return nil, err
 	}
-	cfg.InsecureSkipVerify = noVerify
-	return &dialer{cfg: cfg}, nil
+	if opts.MinVersion, err = parseTLSVersion(v.get("tls_min_version")); err != nil {
+		return nil, err
+	}
+	if opts.Reload, err = parseTimeout(v.get("tls_reload")); err != nil {
+		return nil, err
+	}
+	if pins := v.get("tls_pins"); pins != "" {
+		opts.Pins = strings.Split(pins, ",")
+	}
+	return NewTLSDialer(opts)
 }
 
 // dialer is the default Dialer. It'll use TLS if its cfg member is set,
 // typically through calling TLSDialer.
 type dialer struct {
 	cfg *tls.Config
+
+	// certs, if set, supplies the CA bundle and client certificate, which
+	// are periodically re-read from disk.
+	certs *certFiles
 }
 
 // Dial implements Dialer.
 func (d *dialer) Dial(network, addr string) (net.Conn, error) {
 	if d.cfg != nil {
-		return tls.Dial(network, addr, d.cfg)
+		return tls.Dial(network, addr, d.config(addr))
 	}
 	return net.Dial(network, addr)
 }
 
-// Dial implements Dialer.
+// DialTimeout implements Dialer.
 func (d *dialer) DialTimeout(network, addr string, timeout time.Duration) (net.Conn, error) {
 	if d.cfg != nil {
-		return tls.DialWithDialer(&net.Dialer{Timeout: timeout}, network, addr, d.cfg)
+		return tls.DialWithDialer(&net.Dialer{Timeout: timeout}, network, addr, d.config(addr))
 	}
 	return net.DialTimeout(network, addr, timeout)
 }
 
+// DialContext implements ContextDialer.
+func (d *dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if d.cfg != nil {
+		td := &tls.Dialer{Config: d.config(addr)}
+		return td.DialContext(ctx, network, addr)
+	}
+	var nd net.Dialer
+	return nd.DialContext(ctx, network, addr)
+}
+
+// config returns the TLS configuration used to dial addr.
+func (d *dialer) config(addr string) *tls.Config {
+	if d.certs == nil || d.certs.caFile == "" || d.cfg.InsecureSkipVerify {
+		return d.cfg
+	}
+	// The server's certificate is verified against the current CA bundle
+	// instead of RootCAs, which can't change.
+	name := d.cfg.ServerName
+	if name == "" {
+		name, _, _ = net.SplitHostPort(addr)
+	}
+	cfg := d.cfg.Clone()
+	cfg.InsecureSkipVerify = true
+	cfg.VerifyPeerCertificate = d.certs.verify(name)
+	return cfg
+}
+
 type drv struct{}
 
+var _ driver.DriverContext = (*drv)(nil)
+
 // Open opens a new Bolt connection to the Neo4J database
 func (d *drv) Open(name string) (driver.Conn, error) {
 	return Open(name)
@@ -297,6 +423,18 @@ func (v values) get(k string) string {
 	return v[k]
 }
 
+// bool parses the boolean value of k, which is false if k isn't set.
+func (v values) bool(k string) (bool, error) {
+	switch v.get(k) {
+	case "", "0", "false":
+		return false, nil
+	case "1", "true":
+		return true, nil
+	default:
+		return false, fmt.Errorf("bolt: invalid value for %s: %q", k, v.get(k))
+	}
+}
+
 // merge adds v2 to v, overwriting any new entries.
 func (v values) merge(v2 values) {
 	for k, vv := range v2 {
@@ -2,9 +2,18 @@ package bolt
 
 import (
 	"database/sql"
+	"encoding/binary"
 	"fmt"
+	"io"
+	"math"
+	"net"
 	"os"
+	"sort"
+	"sync"
 	"testing"
+
+	"github.com/sermodigital/bolt/encoding"
+	"github.com/sermodigital/bolt/structures/messages"
 )
 
 var neo4jConnStr = ""
@@ -71,3 +80,223 @@ func clearNeo() {
 		panic("Error running query to clear DB")
 	}
 }
+
+// stubServer is a minimal Bolt server for testing connection handling
+// without a running Neo4j instance. Each message received is passed to handle
+// and the returned messages are written back in order.
+type stubServer struct {
+	ln      net.Listener
+	version version
+	handle  func(sig uint8, fields []interface{}) []interface{}
+
+	// accepts, if set, are the versions the server supports. The server
+	// then ignores version ranges, like servers prior to 4.3, and
+	// negotiates the first version offered that it accepts.
+	accepts []version
+
+	mu    sync.Mutex
+	conns int
+	recv  []stubMessage
+}
+
+// stubMessage is a message received by a stubServer.
+type stubMessage struct {
+	sig    uint8
+	fields []interface{}
+}
+
+// newStubServer starts a stubServer that negotiates version 1 and answers
+// every message with an empty SUCCESS.
+func newStubServer(t *testing.T) *stubServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return serveStub(ln)
+}
+
+// serveStub starts a stubServer that accepts connections from ln.
+func serveStub(ln net.Listener) *stubServer {
+	s := &stubServer{
+		ln:      ln,
+		version: version1_0,
+		handle: func(uint8, []interface{}) []interface{} {
+			return []interface{}{messages.Success{Metadata: map[string]interface{}{}}}
+		},
+	}
+	go s.serve()
+	return s
+}
+
+// dsn returns the connection string for the server.
+func (s *stubServer) dsn() string {
+	return Scheme + s.ln.Addr().String()
+}
+
+func (s *stubServer) Close() error {
+	return s.ln.Close()
+}
+
+func (s *stubServer) serve() {
+	for {
+		c, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns++
+		s.mu.Unlock()
+		go s.serveConn(c)
+	}
+}
+
+func (s *stubServer) serveConn(c net.Conn) {
+	defer c.Close()
+	var hs [len(handshake)]byte
+	if _, err := io.ReadFull(c, hs[:]); err != nil {
+		return
+	}
+	vers := s.version
+	if s.accepts != nil {
+		vers = s.negotiate(hs[4:])
+	}
+	if _, err := c.Write(vers[:]); err != nil {
+		return
+	}
+	enc := encoding.NewEncoder(c)
+	for {
+		sig, fields, err := readStubMessage(c)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.recv = append(s.recv, stubMessage{sig: sig, fields: fields})
+		handle := s.handle
+		s.mu.Unlock()
+		for _, resp := range handle(sig, fields) {
+			if err := enc.Encode(resp); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// negotiate returns the first version in offers that the server accepts,
+// ignoring version ranges.
+func (s *stubServer) negotiate(offers []byte) version {
+	for i := 0; i+4 <= len(offers); i += 4 {
+		offer := version{0x00, 0x00, offers[i+2], offers[i+3]}
+		for _, v := range s.accepts {
+			if v == offer {
+				return v
+			}
+		}
+	}
+	return noSupportedVersions
+}
+
+// received returns the signatures of every message the server has received.
+func (s *stubServer) received() []uint8 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sigs := make([]uint8, len(s.recv))
+	for i, msg := range s.recv {
+		sigs[i] = msg.sig
+	}
+	return sigs
+}
+
+// messages returns every message the server has received with signature
+// sig.
+func (s *stubServer) messages(sig uint8) []stubMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []stubMessage
+	for _, msg := range s.recv {
+		if msg.sig == sig {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+// setHandler replaces the function used to answer messages.
+func (s *stubServer) setHandler(fn func(sig uint8, fields []interface{}) []interface{}) {
+	s.mu.Lock()
+	s.handle = fn
+	s.mu.Unlock()
+}
+
+// readStubMessage reads a single chunked message, returning its signature
+// and fields.
+func readStubMessage(r io.Reader) (uint8, []interface{}, error) {
+	var msg []byte
+	for {
+		var size uint16
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return 0, nil, err
+		}
+		if size == 0 {
+			break
+		}
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return 0, nil, err
+		}
+		msg = append(msg, chunk...)
+	}
+	if len(msg) < 2 {
+		return 0, nil, fmt.Errorf("short message: %x", msg)
+	}
+
+	// Re-frame the fields as a single list so they can be unmarshaled.
+	body := append([]byte{encoding.TinySlice + msg[0] - encoding.TinyStruct}, msg[2:]...)
+	var framed []byte
+	for len(body) > 0 {
+		n := len(body)
+		if n > math.MaxUint16 {
+			n = math.MaxUint16
+		}
+		framed = append(framed, byte(n>>8), byte(n))
+		framed = append(framed, body[:n]...)
+		body = body[n:]
+	}
+	framed = append(framed, 0, 0)
+	fields, err := encoding.Unmarshal(framed)
+	if err != nil {
+		return 0, nil, err
+	}
+	return msg[1], fields.([]interface{}), nil
+}
+
+// echoHandler answers every query with a single row holding the query's
+// parameters, ordered by name.
+func echoHandler(sig uint8, fields []interface{}) []interface{} {
+	success := func(md map[string]interface{}) messages.Success {
+		return messages.Success{Metadata: md}
+	}
+	switch sig {
+	case messages.RunSignature:
+		params, _ := fields[1].(map[string]interface{})
+		keys := make([]string, 0, len(params))
+		for k := range params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		cols := make([]interface{}, len(keys))
+		vals := make([]interface{}, len(keys))
+		for i, k := range keys {
+			cols[i], vals[i] = k, params[k]
+		}
+		return []interface{}{
+			success(map[string]interface{}{"fields": cols}),
+			messages.Record{Values: vals},
+		}
+	case messages.PullAllSignature:
+		return []interface{}{success(map[string]interface{}{})}
+	case messages.GoodbyeSignature:
+		return nil
+	default:
+		return []interface{}{success(map[string]interface{}{})}
+	}
+}
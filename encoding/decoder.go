@@ -79,13 +79,20 @@ func (r *chunkReader) ReadByte() (c byte, err error) {
 // http://alpha.neohq.net/docs/server-manual/bolt-serialization.html#bolt-packstream-structures
 // (version v3.1.0-M02 at the time of writing this.
 //
-// Maps and Slices are a special case, where only map[string]interface{} and
-// []interface{} are supported. The interface for maps and slices may be more
-// permissive in the future.
+// Decode returns maps as map[string]interface{} and lists as []interface{}.
+// DecodeInto can be used to decode into typed Go values instead.
+//
+// Structures with signatures registered with RegisterStructure are decoded
+// by their Unmarshalers.
+//
+// Structures are decoded using the layout of the protocol version set with
+// SetVersion. By default the layouts of Bolt v1 through v4 are used.
 type Decoder struct {
 	r       *chunkReader
 	scratch [512]byte
 	lastErr error
+	major   uint8
+	minor   uint8
 }
 
 // NewDecoder creates a new Decoder object
@@ -96,6 +103,14 @@ func NewDecoder(r io.Reader) *Decoder {
 	return &Decoder{r: &chunkReader{r: &byteReader{Reader: r}}}
 }
 
+// SetVersion sets the negotiated Bolt protocol version, which determines the
+// layout of some structures. For example, as of Bolt v5 nodes and
+// relationships carry element IDs.
+func (d *Decoder) SetVersion(major, minor uint8) {
+	d.major = major
+	d.minor = minor
+}
+
 // Unmarshal is used to marshal an object to the bolt interface encoded bytes
 func Unmarshal(b []byte) (interface{}, error) {
 	return NewDecoder(bytes.NewReader(b)).Decode()
@@ -168,6 +183,23 @@ func (d *Decoder) int64() (int64, error) {
 	return int64(binary.BigEndian.Uint64(d.scratch[:8])), err
 }
 
+// size8, size16, and size32 read the unsigned lengths of strings, bytes,
+// slices, maps, and structures.
+func (d *Decoder) size8() (int64, error) {
+	_, err := io.ReadFull(d.r, d.scratch[:1])
+	return int64(d.scratch[0]), err
+}
+
+func (d *Decoder) size16() (int64, error) {
+	_, err := io.ReadFull(d.r, d.scratch[:2])
+	return int64(binary.BigEndian.Uint16(d.scratch[:2])), err
+}
+
+func (d *Decoder) size32() (int64, error) {
+	_, err := io.ReadFull(d.r, d.scratch[:4])
+	return int64(binary.BigEndian.Uint32(d.scratch[:4])), err
+}
+
 func (d *Decoder) float() (float64, error) {
 	_, err := io.ReadFull(d.r, d.scratch[:8])
 	return math.Float64frombits(binary.BigEndian.Uint64(d.scratch[:8])), err
@@ -216,41 +248,61 @@ func (d *Decoder) decode() (interface{}, error) {
 	case TinyString:
 		return d.decodeString(int(marker) - TinyString)
 	case String8:
-		length, err := d.int8()
+		length, err := d.size8()
 		if err != nil {
 			return nil, err
 		}
 		return d.decodeString(int(length))
 	case String16:
-		length, err := d.int16()
+		length, err := d.size16()
 		if err != nil {
 			return nil, err
 		}
 		return d.decodeString(int(length))
 	case String32:
-		length, err := d.int32()
+		length, err := d.size32()
 		if err != nil {
 			return nil, err
 		}
 		return d.decodeString(int(length))
 
+	// Bytes
+	case Bytes8:
+		length, err := d.size8()
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeBytes(int(length))
+	case Bytes16:
+		length, err := d.size16()
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeBytes(int(length))
+	case Bytes32:
+		length, err := d.size32()
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeBytes(int(length))
+
 	// Slices
 	case TinySlice:
 		return d.decodeSlice(int(marker) - TinySlice)
 	case Slice8:
-		length, err := d.int8()
+		length, err := d.size8()
 		if err != nil {
 			return nil, err
 		}
 		return d.decodeSlice(int(length))
 	case Slice16:
-		length, err := d.int16()
+		length, err := d.size16()
 		if err != nil {
 			return nil, err
 		}
 		return d.decodeSlice(int(length))
 	case Slice32:
-		length, err := d.int32()
+		length, err := d.size32()
 		if err != nil {
 			return nil, err
 		}
@@ -260,19 +312,19 @@ func (d *Decoder) decode() (interface{}, error) {
 	case TinyMap:
 		return d.decodeMap(int(marker) - TinyMap)
 	case Map8:
-		slots, err := d.int8()
+		slots, err := d.size8()
 		if err != nil {
 			return nil, err
 		}
 		return d.decodeMap(int(slots))
 	case Map16:
-		slots, err := d.int16()
+		slots, err := d.size16()
 		if err != nil {
 			return nil, err
 		}
 		return d.decodeMap(int(slots))
 	case Map32:
-		slots, err := d.int32()
+		slots, err := d.size32()
 		if err != nil {
 			return nil, err
 		}
@@ -280,17 +332,19 @@ func (d *Decoder) decode() (interface{}, error) {
 
 	// Structures
 	case TinyStruct:
-		return d.decodeStruct()
+		return d.decodeStruct(int(marker) - TinyStruct)
 	case Struct8:
-		if _, err := d.int8(); err != nil {
+		size, err := d.size8()
+		if err != nil {
 			return nil, err
 		}
-		return d.decodeStruct()
+		return d.decodeStruct(int(size))
 	case Struct16:
-		if _, err := d.int16(); err != nil {
+		size, err := d.size16()
+		if err != nil {
 			return nil, err
 		}
-		return d.decodeStruct()
+		return d.decodeStruct(int(size))
 	}
 }
 
@@ -311,6 +365,14 @@ func (d *Decoder) decodeString(size int) (string, error) {
 	return string(buf), nil
 }
 
+func (d *Decoder) decodeBytes(size int) ([]byte, error) {
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
 func (d *Decoder) decodeSlice(size int) ([]interface{}, error) {
 	slice := make([]interface{}, size)
 	for i := 0; i < size; i++ {
@@ -344,7 +406,7 @@ func (d *Decoder) decodeMap(size int) (map[string]interface{}, error) {
 	return m, nil
 }
 
-func (d *Decoder) decodeStruct() (interface{}, error) {
+func (d *Decoder) decodeStruct(size int) (interface{}, error) {
 	signature, err := d.r.ReadByte()
 	if err != nil {
 		return nil, err
@@ -359,6 +421,28 @@ func (d *Decoder) decodeStruct() (interface{}, error) {
 		return d.decodePath()
 	case graph.UnboundRelationshipSignature:
 		return d.decodeUnboundRelationship()
+	case graph.DateSignature:
+		return d.decodeDate()
+	case graph.TimeSignature:
+		return d.decodeTime()
+	case graph.LocalTimeSignature:
+		return d.decodeLocalTime()
+	case graph.LocalDateTimeSignature:
+		return d.decodeLocalDateTime()
+	case graph.DurationSignature:
+		return d.decodeDuration()
+	case graph.DateTimeSignature:
+		return d.decodeDateTime(false)
+	case graph.DateTimeUTCSignature:
+		return d.decodeDateTime(true)
+	case graph.DateTimeZoneIDSignature:
+		return d.decodeDateTimeZoneID(false)
+	case graph.DateTimeZoneIDUTCSignature:
+		return d.decodeDateTimeZoneID(true)
+	case graph.Point2DSignature:
+		return d.decodePoint2D()
+	case graph.Point3DSignature:
+		return d.decodePoint3D()
 	case messages.RecordSignature:
 		return d.decodeRecordMessage()
 	case messages.FailureSignature:
@@ -370,13 +454,31 @@ func (d *Decoder) decodeStruct() (interface{}, error) {
 	case messages.AckFailureSignature:
 		return messages.AckFailure{}, nil
 	case messages.DiscardAllMessageSignature:
+		// Bolt v4 added a metadata field to DISCARD_ALL and renamed it.
+		if size == 1 {
+			return d.decodeDiscardMessage()
+		}
 		return messages.DiscardAll{}, nil
 	case messages.PullAllSignature:
+		// Bolt v4 added a metadata field to PULL_ALL and renamed it.
+		if size == 1 {
+			return d.decodePullMessage()
+		}
 		return messages.PullAll{}, nil
 	case messages.ResetSignature:
 		return messages.Reset{}, nil
+	case messages.HelloSignature:
+		return d.decodeHelloMessage()
+	case messages.GoodbyeSignature:
+		return messages.Goodbye{}, nil
+	case messages.BeginSignature:
+		return d.decodeBeginMessage()
+	case messages.CommitSignature:
+		return messages.Commit{}, nil
+	case messages.RollbackSignature:
+		return messages.Rollback{}, nil
 	default:
-		return nil, fmt.Errorf("unrecognized type decoding struct with signature %x", signature)
+		return d.decodeRegistered(signature, size)
 	}
 }
 
@@ -413,7 +515,11 @@ func (d *Decoder) decodeNode() (graph.Node, error) {
 	if !ok {
 		return node, fmt.Errorf("expected: Properties map[string]interface{}, but got %T", propertiesInt)
 	}
-	return node, nil
+
+	if d.major >= 5 {
+		node.ElementID, err = d.decodeElementID()
+	}
+	return node, err
 }
 
 func (d *Decoder) decodeRelationship() (graph.Relationship, error) {
@@ -455,7 +561,17 @@ func (d *Decoder) decodeRelationship() (graph.Relationship, error) {
 	if !ok {
 		return rel, fmt.Errorf("expected: Properties map[string]interface{}, but got %T", propertiesInt)
 	}
-	return rel, nil
+
+	if d.major >= 5 {
+		if rel.ElementID, err = d.decodeElementID(); err != nil {
+			return rel, err
+		}
+		if rel.StartNodeElementID, err = d.decodeElementID(); err != nil {
+			return rel, err
+		}
+		rel.EndNodeElementID, err = d.decodeElementID()
+	}
+	return rel, err
 }
 
 func (d *Decoder) decodePath() (graph.Path, error) {
@@ -530,7 +646,25 @@ func (d *Decoder) decodeUnboundRelationship() (graph.UnboundRelationship, error)
 	if !ok {
 		return rel, fmt.Errorf("expected: Properties map[string]interface{}, but got %T", propertiesInt)
 	}
-	return rel, nil
+
+	if d.major >= 5 {
+		rel.ElementID, err = d.decodeElementID()
+	}
+	return rel, err
+}
+
+// decodeElementID decodes the string element IDs added to graph structures in
+// Bolt v5.
+func (d *Decoder) decodeElementID() (string, error) {
+	idInt, err := d.decode()
+	if err != nil {
+		return "", err
+	}
+	id, ok := idInt.(string)
+	if !ok {
+		return "", fmt.Errorf("expected: ElementID string, but got %T", idInt)
+	}
+	return id, nil
 }
 
 func (d *Decoder) decodeRecordMessage() (messages.Record, error) {
@@ -568,3 +702,51 @@ func (d *Decoder) decodeSuccessMessage() (messages.Success, error) {
 	}
 	return messages.Success{Metadata: metadata}, nil
 }
+
+func (d *Decoder) decodeHelloMessage() (messages.Hello, error) {
+	metadataInt, err := d.decode()
+	if err != nil {
+		return messages.Hello{}, err
+	}
+	metadata, ok := metadataInt.(map[string]interface{})
+	if !ok {
+		return messages.Hello{}, fmt.Errorf("expected: Metadata map[string]interface{}, but got %T", metadataInt)
+	}
+	return messages.Hello{Metadata: metadata}, nil
+}
+
+func (d *Decoder) decodeBeginMessage() (messages.Begin, error) {
+	metadataInt, err := d.decode()
+	if err != nil {
+		return messages.Begin{}, err
+	}
+	metadata, ok := metadataInt.(map[string]interface{})
+	if !ok {
+		return messages.Begin{}, fmt.Errorf("expected: Metadata map[string]interface{}, but got %T", metadataInt)
+	}
+	return messages.Begin{Metadata: metadata}, nil
+}
+
+func (d *Decoder) decodePullMessage() (messages.Pull, error) {
+	metadataInt, err := d.decode()
+	if err != nil {
+		return messages.Pull{}, err
+	}
+	metadata, ok := metadataInt.(map[string]interface{})
+	if !ok {
+		return messages.Pull{}, fmt.Errorf("expected: Metadata map[string]interface{}, but got %T", metadataInt)
+	}
+	return messages.Pull{Metadata: metadata}, nil
+}
+
+func (d *Decoder) decodeDiscardMessage() (messages.Discard, error) {
+	metadataInt, err := d.decode()
+	if err != nil {
+		return messages.Discard{}, err
+	}
+	metadata, ok := metadataInt.(map[string]interface{})
+	if !ok {
+		return messages.Discard{}, fmt.Errorf("expected: Metadata map[string]interface{}, but got %T", metadataInt)
+	}
+	return messages.Discard{Metadata: metadata}, nil
+}
@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"time"
 
 	"github.com/sermodigital/bolt/structures"
+	"github.com/sermodigital/bolt/structures/graph"
 )
 
 const (
@@ -45,6 +47,13 @@ const (
 	// Int64 marks the beginning of an int64
 	Int64 = 0xCB
 
+	// Bytes8 marks the beginning of a byte array
+	Bytes8 = 0xCC
+	// Bytes16 marks the beginning of a byte array
+	Bytes16 = 0xCD
+	// Bytes32 marks the beginning of a byte array
+	Bytes32 = 0xCE
+
 	// String8 marks the beginning of a string
 	String8 = 0xD0
 	// String16 marks the beginning of a string
@@ -118,11 +127,25 @@ func MaybeMap(m []byte) bool {
 // http://alpha.neohq.net/docs/server-manual/bolt-serialization.html#bolt-packstream-structures
 // (version v3.1.0-M02 at the time of writing this.
 //
-// Maps and Slices are a special case, where only map[string]interface{} and
-// []interface{} are supported. The interface for maps and slices may be more
-// permissive in the future.
+// Slices and arrays of any type are encoded as lists, and maps with string
+// keys and structs are encoded as maps. Struct fields can be renamed or
+// skipped with "bolt" tags, which work like "json" tags:
+//
+//	type Person struct {
+//		Name  string `bolt:"name"`
+//		Email string `bolt:"email,omitempty"`
+//		Notes string `bolt:"-"`
+//	}
+//
+// time.Time is encoded as a DateTime and time.Duration as a Duration. The
+// DateTime layout depends on the protocol version set with SetVersion.
+//
+// Types implementing Marshaler are encoded as the value returned by their
+// MarshalBolt method.
 type Encoder struct {
-	w *chunkWriter
+	w     *chunkWriter
+	major uint8
+	minor uint8
 }
 
 const DefaultChunkSize = math.MaxUint16
@@ -161,6 +184,14 @@ func (e *Encoder) SetChunkSize(size uint16) error {
 	return nil
 }
 
+// SetVersion sets the negotiated Bolt protocol version, which determines the
+// layout of some structures. For example, as of Bolt v5 DateTimes count
+// seconds in UTC.
+func (e *Encoder) SetVersion(major, minor uint8) {
+	e.major = major
+	e.minor = minor
+}
+
 // Marshal is used to marshal an object to the bolt interface encoded bytes.
 func Marshal(v interface{}) ([]byte, error) {
 	var b bytes.Buffer
@@ -256,6 +287,12 @@ func (e *Encoder) encode(val interface{}) error {
 	switch val := val.(type) {
 	case nil:
 		return e.w.write(Nil)
+	case Marshaler:
+		v, err := val.MarshalBolt()
+		if err != nil {
+			return err
+		}
+		return e.encode(v)
 	case bool:
 		if val {
 			return e.w.write(True)
@@ -296,14 +333,20 @@ func (e *Encoder) encode(val interface{}) error {
 		return e.encodeFloat(val)
 	case string:
 		return e.encodeString(val)
+	case []byte:
+		return e.encodeBytes(val)
 	case []interface{}:
 		return e.encodeSlice(val)
 	case map[string]interface{}:
 		return e.encodeMap(val)
+	case time.Time:
+		return e.encodeStructure(dateTime(val, e.major >= 5))
+	case time.Duration:
+		return e.encodeStructure(graph.DurationOf(val))
 	case structures.Structure:
 		return e.encodeStructure(val)
 	default:
-		return fmt.Errorf("unrecognized type when encoding data for Bolt transport: %T", val)
+		return e.encodeReflect(val)
 	}
 }
 
@@ -407,8 +450,54 @@ func (e *Encoder) encodeString(str string) (err error) {
 	}
 }
 
-func (e *Encoder) encodeSlice(val []interface{}) (err error) {
+func (e *Encoder) encodeBytes(val []byte) (err error) {
 	switch length := len(val); {
+	case length <= math.MaxUint8:
+		if err = e.w.write(Bytes8); err != nil {
+			return err
+		}
+		if err = e.write(uint8(length)); err != nil {
+			return err
+		}
+	case length <= math.MaxUint16:
+		if err = e.w.write(Bytes16); err != nil {
+			return err
+		}
+		if err = e.write(uint16(length)); err != nil {
+			return err
+		}
+	case length <= math.MaxUint32:
+		if err = e.w.write(Bytes32); err != nil {
+			return err
+		}
+		if err = e.write(uint32(length)); err != nil {
+			return err
+		}
+	default:
+		return errors.New("byte array too long to write")
+	}
+	_, err = e.w.Write(val)
+	return err
+}
+
+func (e *Encoder) encodeSlice(val []interface{}) (err error) {
+	if err = e.encodeSliceHeader(len(val)); err != nil {
+		return err
+	}
+
+	// Encode Slice values
+	for _, item := range val {
+		if err = e.encode(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeSliceHeader writes the marker and size of a slice with length
+// items.
+func (e *Encoder) encodeSliceHeader(length int) (err error) {
+	switch {
 	case length <= 15:
 		err = e.w.write(TinySlice + uint8(length))
 		if err != nil {
@@ -438,18 +527,29 @@ func (e *Encoder) encodeSlice(val []interface{}) (err error) {
 	default:
 		return errors.New("slice too long to write")
 	}
+	return nil
+}
 
-	// Encode Slice values
-	for _, item := range val {
-		if err = e.encode(item); err != nil {
+func (e *Encoder) encodeMap(val map[string]interface{}) (err error) {
+	if err = e.encodeMapHeader(len(val)); err != nil {
+		return err
+	}
+
+	// Encode Map values
+	for k, v := range val {
+		if err := e.encode(k); err != nil {
+			return err
+		}
+		if err := e.encode(v); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (e *Encoder) encodeMap(val map[string]interface{}) (err error) {
-	switch length := len(val); {
+// encodeMapHeader writes the marker and size of a map with length entries.
+func (e *Encoder) encodeMapHeader(length int) (err error) {
+	switch {
 	case length <= 15:
 		err = e.w.write(TinyMap + uint8(length))
 		if err != nil {
@@ -479,16 +579,6 @@ func (e *Encoder) encodeMap(val map[string]interface{}) (err error) {
 	default:
 		return errors.New("map too long to write")
 	}
-
-	// Encode Map values
-	for k, v := range val {
-		if err := e.encode(k); err != nil {
-			return err
-		}
-		if err := e.encode(v); err != nil {
-			return err
-		}
-	}
 	return nil
 }
 
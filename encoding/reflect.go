@@ -0,0 +1,258 @@
+package encoding
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// encodeReflect encodes types the Encoder doesn't handle explicitly: slices
+// and arrays of any type, maps keyed by strings, structs, pointers, and types
+// whose underlying type is a basic type.
+//
+// Structs are encoded as maps. Each exported field is keyed by its name, or
+// by the name given in its "bolt" tag:
+//
+//	// Field is encoded with the key "name".
+//	Field string `bolt:"name"`
+//
+//	// Field is encoded with the key "name" unless it's empty.
+//	Field string `bolt:"name,omitempty"`
+//
+//	// Field is skipped.
+//	Field string `bolt:"-"`
+//
+// Fields of embedded structs are encoded as if they belonged to the outer
+// struct, unless the outer struct has a field with the same name.
+func (e *Encoder) encodeReflect(val interface{}) error {
+	v := reflect.ValueOf(val)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return e.w.write(Nil)
+		}
+		return e.encode(v.Elem().Interface())
+	case reflect.Bool:
+		return e.encode(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return e.encodeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := v.Uint(); u > math.MaxInt64 {
+			return fmt.Errorf("integer too big: %d. Max integer supported: %d", u, math.MaxInt64)
+		}
+		return e.encodeInt(int64(v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		return e.encodeFloat(v.Float())
+	case reflect.String:
+		return e.encodeString(v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return e.encodeBytes(bytesOf(v))
+		}
+		if err := e.encodeSliceHeader(v.Len()); err != nil {
+			return err
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := e.encode(v.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type when encoding data for Bolt transport: %s", v.Type().Key())
+		}
+		if err := e.encodeMapHeader(v.Len()); err != nil {
+			return err
+		}
+		for _, k := range v.MapKeys() {
+			if err := e.encodeString(k.String()); err != nil {
+				return err
+			}
+			if err := e.encode(v.MapIndex(k).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	default:
+		return fmt.Errorf("unrecognized type when encoding data for Bolt transport: %T", val)
+	}
+}
+
+// encodeStruct encodes a struct as a map of its fields.
+func (e *Encoder) encodeStruct(v reflect.Value) error {
+	fields := cachedFields(v.Type())
+	vals := make([]reflect.Value, len(fields))
+	n := 0
+	for i, f := range fields {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+		vals[i] = fv
+		n++
+	}
+
+	if err := e.encodeMapHeader(n); err != nil {
+		return err
+	}
+	for i, f := range fields {
+		if !vals[i].IsValid() {
+			continue
+		}
+		if err := e.encodeString(f.name); err != nil {
+			return err
+		}
+		if err := e.encode(vals[i].Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bytesOf returns the contents of a byte slice or array.
+func bytesOf(v reflect.Value) []byte {
+	if v.Kind() == reflect.Slice {
+		return v.Bytes()
+	}
+	b := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(b), v)
+	return b
+}
+
+// field is an exported struct field and how it should be encoded and
+// decoded.
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+var fieldCache struct {
+	sync.RWMutex
+	m map[reflect.Type][]field
+}
+
+// cachedFields is like typeFields but caches the result.
+func cachedFields(t reflect.Type) []field {
+	fieldCache.RLock()
+	f, ok := fieldCache.m[t]
+	fieldCache.RUnlock()
+	if ok {
+		return f
+	}
+
+	f = typeFields(t)
+	fieldCache.Lock()
+	if fieldCache.m == nil {
+		fieldCache.m = make(map[reflect.Type][]field)
+	}
+	fieldCache.m[t] = f
+	fieldCache.Unlock()
+	return f
+}
+
+// typeFields returns the fields of struct type t that should be encoded or
+// decoded. Fields of untagged, embedded structs are promoted unless they
+// conflict with a field closer to the surface.
+func typeFields(t reflect.Type) []field {
+	var (
+		fields   []field
+		seen     = make(map[string]bool)
+		embedded []reflect.StructField
+	)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("bolt")
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseTag(tag)
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, sf)
+				continue
+			}
+		}
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		seen[name] = true
+		fields = append(fields, field{
+			name:      name,
+			index:     sf.Index,
+			omitEmpty: opts == "omitempty",
+		})
+	}
+
+	for _, sf := range embedded {
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		for _, f := range typeFields(ft) {
+			if seen[f.name] {
+				continue
+			}
+			seen[f.name] = true
+			f.index = append([]int{sf.Index[0]}, f.index...)
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// parseTag splits a "bolt" struct tag into its name and options.
+func parseTag(tag string) (string, string) {
+	if i := strings.Index(tag, ","); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but reports false instead
+// of panicking if it traverses a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// isEmptyValue reports whether v is empty for the purposes of omitempty.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package encoding
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sermodigital/bolt/structures/graph"
+	"github.com/sermodigital/bolt/structures/messages"
+)
+
+// Marshaler is implemented by types that can encode themselves. MarshalBolt
+// returns a value the Encoder knows how to encode, usually a
+// structures.Structure.
+type Marshaler interface {
+	MarshalBolt() (interface{}, error)
+}
+
+// Unmarshaler is implemented by types that can decode themselves from the
+// fields of a structure. Unmarshalers are created by the Decoder for the
+// signatures they're registered to with RegisterStructure.
+type Unmarshaler interface {
+	UnmarshalBolt(fields []interface{}) error
+}
+
+// builtin contains the signatures of the structures defined by the Bolt
+// protocol, which cannot be registered.
+var builtin = map[uint8]bool{
+	graph.NodeSignature:                 true,
+	graph.RelationshipSignature:         true,
+	graph.PathSignature:                 true,
+	graph.UnboundRelationshipSignature:  true,
+	graph.DateSignature:                 true,
+	graph.TimeSignature:                 true,
+	graph.LocalTimeSignature:            true,
+	graph.LocalDateTimeSignature:        true,
+	graph.DurationSignature:             true,
+	graph.DateTimeSignature:             true,
+	graph.DateTimeUTCSignature:          true,
+	graph.DateTimeZoneIDSignature:       true,
+	graph.DateTimeZoneIDUTCSignature:    true,
+	graph.Point2DSignature:              true,
+	graph.Point3DSignature:              true,
+	messages.InitSignature:              true,
+	messages.GoodbyeSignature:           true,
+	messages.AckFailureSignature:        true,
+	messages.ResetSignature:             true,
+	messages.RunSignature:               true,
+	messages.BeginSignature:             true,
+	messages.CommitSignature:            true,
+	messages.RollbackSignature:          true,
+	messages.DiscardAllMessageSignature: true,
+	messages.PullAllSignature:           true,
+	messages.SuccessSignature:           true,
+	messages.RecordSignature:            true,
+	messages.IgnoredSignature:           true,
+	messages.FailureSignature:           true,
+}
+
+var registry struct {
+	sync.RWMutex
+	m map[uint8]func() Unmarshaler
+}
+
+// RegisterStructure registers fn to create the Unmarshaler for structures
+// with the given signature. When the Decoder encounters such a structure it
+// calls fn, passes the structure's fields to the result's UnmarshalBolt
+// method, and returns the result.
+//
+// RegisterStructure is meant to be called from init functions. It panics if
+// the signature belongs to a structure defined by the Bolt protocol or has
+// already been registered.
+func RegisterStructure(signature uint8, fn func() Unmarshaler) {
+	if fn == nil {
+		panic("encoding: RegisterStructure function is nil")
+	}
+	if builtin[signature] {
+		panic(fmt.Sprintf("encoding: cannot register builtin structure signature %#x", signature))
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.m[signature]; ok {
+		panic(fmt.Sprintf("encoding: RegisterStructure called twice for signature %#x", signature))
+	}
+	if registry.m == nil {
+		registry.m = make(map[uint8]func() Unmarshaler)
+	}
+	registry.m[signature] = fn
+}
+
+// registered returns the function registered for signature, if any.
+func registered(signature uint8) (func() Unmarshaler, bool) {
+	registry.RLock()
+	fn, ok := registry.m[signature]
+	registry.RUnlock()
+	return fn, ok
+}
+
+// decodeRegistered decodes a structure with size fields using the
+// Unmarshaler registered for its signature.
+func (d *Decoder) decodeRegistered(signature uint8, size int) (interface{}, error) {
+	fn, ok := registered(signature)
+	if !ok {
+		return nil, fmt.Errorf("unrecognized type decoding struct with signature %x", signature)
+	}
+	fields := make([]interface{}, size)
+	for i := range fields {
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = v
+	}
+	u := fn()
+	if err := u.UnmarshalBolt(fields); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
@@ -0,0 +1,78 @@
+package encoding
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sermodigital/bolt/structures/graph"
+)
+
+const moneySignature = 0x4D
+
+type money struct {
+	Cents    int64
+	Currency string
+}
+
+func (m money) MarshalBolt() (interface{}, error) {
+	return structure{moneySignature, []interface{}{m.Cents, m.Currency}}, nil
+}
+
+func (m *money) UnmarshalBolt(fields []interface{}) error {
+	if len(fields) != 2 {
+		return errors.New("money: expected 2 fields")
+	}
+	var ok bool
+	if m.Cents, ok = fields[0].(int64); !ok {
+		return errtype("int64", fields[0])
+	}
+	if m.Currency, ok = fields[1].(string); !ok {
+		return errtype("string", fields[1])
+	}
+	return nil
+}
+
+func init() {
+	RegisterStructure(moneySignature, func() Unmarshaler { return new(money) })
+}
+
+func TestRegisterStructure(t *testing.T) {
+	in := map[string]interface{}{"price": money{Cents: 1999, Currency: "EUR"}}
+	b, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"price": &money{Cents: 1999, Currency: "EUR"}}
+	if !reflect.DeepEqual(v, want) {
+		t.Fatalf("wanted %#v, got %#v", want, v)
+	}
+
+	var out struct {
+		Price money `bolt:"price"`
+	}
+	if err := UnmarshalInto(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Price != in["price"] {
+		t.Fatalf("wanted %#v, got %#v", in["price"], out.Price)
+	}
+}
+
+func TestRegisterStructure_Panics(t *testing.T) {
+	for _, sig := range []uint8{graph.NodeSignature, moneySignature} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("wanted registering %#x to panic", sig)
+				}
+			}()
+			RegisterStructure(sig, func() Unmarshaler { return new(money) })
+		}()
+	}
+}
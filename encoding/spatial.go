@@ -0,0 +1,61 @@
+package encoding
+
+import (
+	"fmt"
+
+	"github.com/sermodigital/bolt/structures/graph"
+)
+
+// decodeSRID decodes the spatial reference identifier of a point.
+func (d *Decoder) decodeSRID() (int64, error) {
+	sridInt, err := d.decode()
+	if err != nil {
+		return 0, err
+	}
+	srid, ok := sridInt.(int64)
+	if !ok {
+		return 0, fmt.Errorf("expected: SRID int64, but got %T", sridInt)
+	}
+	return srid, nil
+}
+
+// decodeCoords decodes the n coordinates of a point.
+func (d *Decoder) decodeCoords(n int) ([]float64, error) {
+	coords := make([]float64, n)
+	for i := range coords {
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		coords[i], ok = v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("expected: coordinate float64, but got %T", v)
+		}
+	}
+	return coords, nil
+}
+
+func (d *Decoder) decodePoint2D() (graph.Point2D, error) {
+	srid, err := d.decodeSRID()
+	if err != nil {
+		return graph.Point2D{}, err
+	}
+	c, err := d.decodeCoords(2)
+	if err != nil {
+		return graph.Point2D{}, err
+	}
+	return graph.Point2D{SRID: srid, X: c[0], Y: c[1]}, nil
+}
+
+func (d *Decoder) decodePoint3D() (graph.Point3D, error) {
+	srid, err := d.decodeSRID()
+	if err != nil {
+		return graph.Point3D{}, err
+	}
+	c, err := d.decodeCoords(3)
+	if err != nil {
+		return graph.Point3D{}, err
+	}
+	return graph.Point3D{SRID: srid, X: c[0], Y: c[1], Z: c[2]}, nil
+}
@@ -0,0 +1,178 @@
+package encoding
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sermodigital/bolt/structures/graph"
+)
+
+// structure is a generic structures.Structure used to encode types that
+// don't have one of their own, like time.Time.
+type structure struct {
+	signature uint8
+	fields    []interface{}
+}
+
+func (s structure) Signature() uint8 {
+	return s.signature
+}
+
+func (s structure) Fields() []interface{} {
+	return s.fields
+}
+
+// dateTime returns the DateTime structure for t. If utc is true the Bolt v5
+// layout, which counts seconds in UTC, is used.
+func dateTime(t time.Time, utc bool) structure {
+	_, offset := t.Zone()
+	secs, nanos := t.Unix(), int64(t.Nanosecond())
+
+	// Only send the zone ID if the server would derive the same offset from
+	// it. Times in UTC or Local, and zones made with time.FixedZone or by
+	// time.Parse for an unknown abbreviation, like "MST" with offset 0,
+	// might not, and are sent with their offset instead.
+	loc := t.Location()
+	if loc == time.UTC || loc == time.Local || !zoneIDMatches(t, offset) {
+		if utc {
+			return structure{graph.DateTimeUTCSignature, []interface{}{secs, nanos, int64(offset)}}
+		}
+		return structure{graph.DateTimeSignature, []interface{}{secs + int64(offset), nanos, int64(offset)}}
+	}
+	if utc {
+		return structure{graph.DateTimeZoneIDUTCSignature, []interface{}{secs, nanos, loc.String()}}
+	}
+	return structure{graph.DateTimeZoneIDSignature, []interface{}{secs + int64(offset), nanos, loc.String()}}
+}
+
+// zones caches the results of loadZone.
+var zones sync.Map // map[string]*time.Location
+
+// loadZone returns the location time.LoadLocation resolves name to, or nil
+// if it doesn't.
+func loadZone(name string) *time.Location {
+	if name == "" || name == "Local" {
+		return nil
+	}
+	if loc, found := zones.Load(name); found {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		loc = nil
+	}
+	zones.Store(name, loc)
+	return loc
+}
+
+// zoneIDMatches reports whether the name of t's location is a time zone ID
+// with the given offset at t.
+func zoneIDMatches(t time.Time, offset int) bool {
+	loaded := loadZone(t.Location().String())
+	if loaded == nil {
+		return false
+	}
+	_, off := t.In(loaded).Zone()
+	return off == offset
+}
+
+// decodeInts decodes n integer fields of a structure.
+func (d *Decoder) decodeInts(n int) ([]int64, error) {
+	ints := make([]int64, n)
+	for i := range ints {
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		ints[i], ok = v.(int64)
+		if !ok {
+			return nil, errtype("int64", v)
+		}
+	}
+	return ints, nil
+}
+
+func (d *Decoder) decodeDate() (graph.Date, error) {
+	f, err := d.decodeInts(1)
+	if err != nil {
+		return graph.Date{}, err
+	}
+	const day = 24 * 60 * 60
+	return graph.Date(time.Unix(f[0]*day, 0).UTC()), nil
+}
+
+func (d *Decoder) decodeTime() (graph.Time, error) {
+	f, err := d.decodeInts(2)
+	if err != nil {
+		return graph.Time{}, err
+	}
+	loc := time.FixedZone("", int(f[1]))
+	return graph.Time(time.Date(0, time.January, 1, 0, 0, 0, int(f[0]), loc)), nil
+}
+
+func (d *Decoder) decodeLocalTime() (graph.LocalTime, error) {
+	f, err := d.decodeInts(1)
+	if err != nil {
+		return graph.LocalTime{}, err
+	}
+	return graph.LocalTime(time.Date(0, time.January, 1, 0, 0, 0, int(f[0]), time.UTC)), nil
+}
+
+func (d *Decoder) decodeLocalDateTime() (graph.LocalDateTime, error) {
+	f, err := d.decodeInts(2)
+	if err != nil {
+		return graph.LocalDateTime{}, err
+	}
+	return graph.LocalDateTime(time.Unix(f[0], f[1]).UTC()), nil
+}
+
+func (d *Decoder) decodeDuration() (graph.Duration, error) {
+	f, err := d.decodeInts(4)
+	if err != nil {
+		return graph.Duration{}, err
+	}
+	return graph.Duration{Months: f[0], Days: f[1], Seconds: f[2], Nanos: f[3]}, nil
+}
+
+// decodeDateTime decodes a DateTime with a UTC offset. If utc is false, the
+// seconds are counted in local time.
+func (d *Decoder) decodeDateTime(utc bool) (time.Time, error) {
+	f, err := d.decodeInts(3)
+	if err != nil {
+		return time.Time{}, err
+	}
+	secs, nanos, offset := f[0], f[1], f[2]
+	if !utc {
+		secs -= offset
+	}
+	return time.Unix(secs, nanos).In(time.FixedZone("", int(offset))), nil
+}
+
+// decodeDateTimeZoneID decodes a DateTime with a time zone name. If utc is
+// false, the seconds are counted in local time.
+func (d *Decoder) decodeDateTimeZoneID(utc bool) (time.Time, error) {
+	f, err := d.decodeInts(2)
+	if err != nil {
+		return time.Time{}, err
+	}
+	zoneInt, err := d.decode()
+	if err != nil {
+		return time.Time{}, err
+	}
+	zone, ok := zoneInt.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("expected: ZoneID string, but got %T", zoneInt)
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t := time.Unix(f[0], f[1]).UTC()
+	if utc {
+		return t.In(loc), nil
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(),
+		t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc), nil
+}
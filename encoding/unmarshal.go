@@ -0,0 +1,308 @@
+package encoding
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sermodigital/bolt/structures/graph"
+)
+
+// UnmarshalTypeError describes a decoded value that could not be stored in a
+// Go value of a specific type.
+type UnmarshalTypeError struct {
+	Value interface{}  // the decoded value
+	Type  reflect.Type // the type of the Go value it could not be assigned to
+	Path  string       // the location of the value, e.g. ".properties.age"
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("cannot unmarshal %T into Go value of type %s", e.Value, e.Type)
+	}
+	return fmt.Sprintf("cannot unmarshal %T into Go value of type %s at %s", e.Value, e.Type, e.Path)
+}
+
+// UnmarshalInto decodes the bolt encoded bytes into the value pointed to by
+// v. See Decoder.DecodeInto for how values are assigned.
+func UnmarshalInto(b []byte, v interface{}) error {
+	return NewDecoder(bytes.NewReader(b)).DecodeInto(v)
+}
+
+// DecodeInto decodes the next object from the stream and stores it in the
+// value pointed to by v, which must be a non-nil pointer.
+//
+// Values are assigned much like encoding/json assigns them:
+//
+//   - Values are stored as-is in interface{} values and values of the same
+//     type, e.g. graph.Node. Registered structures are also stored in values
+//     of the type their Unmarshalers point to.
+//   - Integers, floats, strings, booleans, and byte arrays are stored in Go
+//     values of the same kind, as long as they do not overflow.
+//   - Lists are stored in slices and arrays.
+//   - Maps are stored in string-keyed maps and structs. Keys are matched to
+//     struct fields by the names given in their "bolt" tags, falling back to a
+//     case-insensitive match of the field name. Unknown keys are ignored.
+//   - Nodes and relationships are stored in maps and structs as if they were
+//     maps with the keys "id", "element_id", "labels", "type", "start_id",
+//     "end_id", "start_element_id", "end_element_id", and "properties".
+//   - Null sets pointers, maps, slices, and interfaces to nil, and leaves other
+//     values unchanged.
+//   - DateTimes, LocalDateTimes, and Dates are stored in time.Time values, and
+//     Durations in time.Duration values.
+//
+// If a value cannot be stored an *UnmarshalTypeError is returned.
+func (d *Decoder) DecodeInto(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("cannot decode into non-pointer %T", v)
+	}
+	val, err := d.Decode()
+	if err != nil {
+		return err
+	}
+	return assign(rv.Elem(), val, "")
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// assign stores val in dst. path is the location of val within the decoded
+// value and is used in errors.
+func assign(dst reflect.Value, val interface{}, path string) error {
+	if val == nil {
+		switch dst.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+			dst.Set(reflect.Zero(dst.Type()))
+		}
+		return nil
+	}
+
+	src := reflect.ValueOf(val)
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+	// Unmarshalers are usually pointers.
+	if src.Kind() == reflect.Ptr && !src.IsNil() && src.Elem().Type().AssignableTo(dst.Type()) {
+		dst.Set(src.Elem())
+		return nil
+	}
+
+	mismatch := &UnmarshalTypeError{Value: val, Type: dst.Type(), Path: path}
+	switch dst.Kind() {
+	case reflect.Ptr:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return assign(dst.Elem(), val, path)
+	case reflect.Bool:
+		b, ok := val.(bool)
+		if !ok {
+			return mismatch
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if dst.Type() == durationType {
+			d, ok := val.(graph.Duration)
+			if !ok {
+				return mismatch
+			}
+			dst.SetInt(int64(d.Duration()))
+			return nil
+		}
+		i, ok := val.(int64)
+		if !ok || dst.OverflowInt(i) {
+			return mismatch
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, ok := val.(int64)
+		if !ok || i < 0 || dst.OverflowUint(uint64(i)) {
+			return mismatch
+		}
+		dst.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch x := val.(type) {
+		case float64:
+			f = x
+		case int64:
+			f = float64(x)
+		default:
+			return mismatch
+		}
+		if dst.Kind() == reflect.Float32 && math.Abs(f) > math.MaxFloat32 && !math.IsInf(f, 0) {
+			return mismatch
+		}
+		dst.SetFloat(f)
+	case reflect.String:
+		s, ok := val.(string)
+		if !ok {
+			return mismatch
+		}
+		dst.SetString(s)
+	case reflect.Slice:
+		if b, ok := val.([]byte); ok && dst.Type().Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes(append([]byte(nil), b...))
+			return nil
+		}
+		list, ok := val.([]interface{})
+		if !ok {
+			return mismatch
+		}
+		s := reflect.MakeSlice(dst.Type(), len(list), len(list))
+		for i, item := range list {
+			if err := assign(s.Index(i), item, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+		dst.Set(s)
+	case reflect.Array:
+		list, ok := val.([]interface{})
+		if !ok || len(list) != dst.Len() {
+			return mismatch
+		}
+		for i, item := range list {
+			if err := assign(dst.Index(i), item, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		m, ok := asMap(val)
+		if !ok || dst.Type().Key().Kind() != reflect.String {
+			return mismatch
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(dst.Type()))
+		}
+		et := dst.Type().Elem()
+		for k, item := range m {
+			elem := reflect.New(et).Elem()
+			if err := assign(elem, item, path+"."+k); err != nil {
+				return err
+			}
+			dst.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), elem)
+		}
+	case reflect.Struct:
+		if dst.Type() == timeType {
+			t, ok := asTime(val)
+			if !ok {
+				return mismatch
+			}
+			dst.Set(reflect.ValueOf(t))
+			return nil
+		}
+		m, ok := asMap(val)
+		if !ok {
+			return mismatch
+		}
+		return assignStruct(dst, m, path)
+	default:
+		return mismatch
+	}
+	return nil
+}
+
+// assignStruct stores the entries of m in the matching fields of dst.
+func assignStruct(dst reflect.Value, m map[string]interface{}, path string) error {
+	fields := cachedFields(dst.Type())
+	for k, item := range m {
+		f, ok := findField(fields, k)
+		if !ok {
+			continue
+		}
+		fv := dst
+		for i, x := range f.index {
+			if i > 0 && fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					// Like encoding/json, a nil pointer to an unexported
+					// embedded struct can't be allocated.
+					if !fv.CanSet() {
+						return fmt.Errorf("cannot set embedded pointer to unexported struct %s at %s", fv.Type().Elem(), path+"."+k)
+					}
+					fv.Set(reflect.New(fv.Type().Elem()))
+				}
+				fv = fv.Elem()
+			}
+			fv = fv.Field(x)
+		}
+		if err := assign(fv, item, path+"."+k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findField returns the field named key, preferring an exact match.
+func findField(fields []field, key string) (field, bool) {
+	for _, f := range fields {
+		if f.name == key {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, key) {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// asMap returns val as a map if it's a map, node, or relationship.
+func asMap(val interface{}) (map[string]interface{}, bool) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		return v, true
+	case graph.Node:
+		labels := make([]interface{}, len(v.Labels))
+		for i, l := range v.Labels {
+			labels[i] = l
+		}
+		return map[string]interface{}{
+			"id":         v.NodeIdentity,
+			"element_id": v.ElementID,
+			"labels":     labels,
+			"properties": v.Properties,
+		}, true
+	case graph.Relationship:
+		return map[string]interface{}{
+			"id":               v.RelIdentity,
+			"element_id":       v.ElementID,
+			"start_id":         v.StartNodeIdentity,
+			"end_id":           v.EndNodeIdentity,
+			"start_element_id": v.StartNodeElementID,
+			"end_element_id":   v.EndNodeElementID,
+			"type":             v.Type,
+			"properties":       v.Properties,
+		}, true
+	case graph.UnboundRelationship:
+		return map[string]interface{}{
+			"id":         v.RelIdentity,
+			"element_id": v.ElementID,
+			"type":       v.Type,
+			"properties": v.Properties,
+		}, true
+	default:
+		return nil, false
+	}
+}
+
+// asTime returns val as a time.Time if it's a DateTime, LocalDateTime, or
+// Date.
+func asTime(val interface{}) (time.Time, bool) {
+	switch v := val.(type) {
+	case graph.LocalDateTime:
+		return time.Time(v), true
+	case graph.Date:
+		return time.Time(v), true
+	default:
+		return time.Time{}, false
+	}
+}
@@ -0,0 +1,138 @@
+package encoding
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sermodigital/bolt/structures/graph"
+)
+
+type person struct {
+	Name    string   `bolt:"name"`
+	Age     uint8    `bolt:"age"`
+	Email   *string  `bolt:"email"`
+	Tags    []string `bolt:"tags"`
+	Ignored string   `bolt:"-"`
+}
+
+type personNode struct {
+	ID         int64    `bolt:"id"`
+	Labels     []string `bolt:"labels"`
+	Properties person   `bolt:"properties"`
+}
+
+func TestUnmarshalInto(t *testing.T) {
+	email := "alice@example.com"
+	born := time.Date(1990, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		in   interface{}
+		ptr  interface{}
+		want interface{}
+	}{
+		{[]interface{}{"a", "b"}, new([]string), []string{"a", "b"}},
+		{[]interface{}{int64(1), int64(2)}, new([2]int), [2]int{1, 2}},
+		{map[string]interface{}{"k": int64(1)}, new(map[string]int32), map[string]int32{"k": 1}},
+		{int64(3), new(float64), 3.0},
+		{[]byte{1, 2}, new([]byte), []byte{1, 2}},
+		{nil, new(*int), (*int)(nil)},
+		{graph.LocalDateTimeOf(born), new(time.Time), born},
+		{graph.DurationOf(90 * time.Second), new(time.Duration), 90 * time.Second},
+		{
+			map[string]interface{}{
+				"name":    "Alice",
+				"AGE":     int64(30),
+				"email":   email,
+				"tags":    []interface{}{"x"},
+				"Ignored": "ignored",
+				"unknown": true,
+			},
+			new(person),
+			person{Name: "Alice", Age: 30, Email: &email, Tags: []string{"x"}},
+		},
+		{
+			graph.Node{
+				NodeIdentity: 7,
+				Labels:       []string{"Person"},
+				Properties:   map[string]interface{}{"name": "Bob"},
+			},
+			new(personNode),
+			personNode{ID: 7, Labels: []string{"Person"}, Properties: person{Name: "Bob"}},
+		},
+	}
+	for i, tt := range tests {
+		b, err := Marshal(tt.in)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if err := UnmarshalInto(b, tt.ptr); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if got := reflect.ValueOf(tt.ptr).Elem().Interface(); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("#%d: wanted %#v, got %#v", i, tt.want, got)
+		}
+	}
+}
+
+func TestUnmarshalInto_TypeError(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		ptr  interface{}
+		path string
+	}{
+		{"x", new(int), ""},
+		{int64(300), new(uint8), ""},
+		{int64(-1), new(uint), ""},
+		{[]interface{}{"a", int64(1)}, new([]string), "[1]"},
+		{
+			graph.Node{Properties: map[string]interface{}{"age": "thirty"}},
+			new(personNode),
+			".properties.age",
+		},
+	}
+	for i, tt := range tests {
+		b, err := Marshal(tt.in)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		err = UnmarshalInto(b, tt.ptr)
+		terr, ok := err.(*UnmarshalTypeError)
+		if !ok {
+			t.Fatalf("#%d: wanted *UnmarshalTypeError, got %v", i, err)
+		}
+		if terr.Path != tt.path {
+			t.Fatalf("#%d: wanted path %q, got %q", i, tt.path, terr.Path)
+		}
+	}
+
+	if err := UnmarshalInto(nil, person{}); err == nil {
+		t.Fatal("wanted an error decoding into a non-pointer")
+	}
+}
+
+type inner struct {
+	Name string `bolt:"name"`
+}
+
+type embedsUnexported struct {
+	*inner
+}
+
+func TestUnmarshalInto_UnexportedEmbeddedPointer(t *testing.T) {
+	b, err := Marshal(map[string]interface{}{"name": "Alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v embedsUnexported
+	if err := UnmarshalInto(b, &v); err == nil {
+		t.Fatal("wanted an error decoding into a nil pointer to an unexported embedded struct")
+	}
+
+	v.inner = &inner{}
+	if err := UnmarshalInto(b, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "Alice" {
+		t.Fatalf("wanted Alice, got %q", v.Name)
+	}
+}
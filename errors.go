@@ -0,0 +1,55 @@
+package bolt
+
+import (
+	"strings"
+
+	"github.com/sermodigital/bolt/structures/messages"
+)
+
+// Neo4jError is a failure reported by the server, e.g. a syntax error or a
+// constraint violation. Its Code has the form
+//
+//	Neo.[Classification].[Category].[Title]
+//
+// for example Neo.ClientError.Schema.ConstraintValidationFailed.
+type Neo4jError struct {
+	Code           string
+	Message        string
+	Classification string // ClientError, TransientError, or DatabaseError
+	Category       string
+	Title          string
+}
+
+func (e *Neo4jError) Error() string {
+	return "bolt: " + e.Code + ": " + e.Message
+}
+
+// newNeo4jError parses the metadata of a FAILURE message.
+func newNeo4jError(fail messages.Failure) *Neo4jError {
+	e := &Neo4jError{}
+	e.Code, _ = fail.Metadata["code"].(string)
+	e.Message, _ = fail.Metadata["message"].(string)
+	parts := strings.Split(e.Code, ".")
+	if len(parts) == 4 {
+		e.Classification = parts[1]
+		e.Category = parts[2]
+		e.Title = parts[3]
+	}
+	return e
+}
+
+// Retryable reports whether the transaction that caused the error may succeed
+// if it's retried, for example after a deadlock or a cluster leader switch.
+func (e *Neo4jError) Retryable() bool {
+	switch e.Code {
+	case "Neo.TransientError.Transaction.Terminated",
+		"Neo.TransientError.Transaction.LockClientStopped":
+		// These are caused by the client terminating the transaction.
+		return false
+	case "Neo.ClientError.Cluster.NotALeader",
+		"Neo.ClientError.General.ForbiddenOnReadOnlyDatabase":
+		// The cluster's leader changed.
+		return true
+	}
+	return e.Classification == "TransientError"
+}
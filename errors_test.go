@@ -0,0 +1,67 @@
+package bolt
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestNeo4jError(t *testing.T) {
+	for _, vers := range []version{version1_0, version3_0} {
+		t.Run(vers.String(), func(t *testing.T) {
+			testNeo4jError(t, vers)
+		})
+	}
+}
+
+func testNeo4jError(t *testing.T, vers version) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.version = vers
+	srv.setHandler(failingHandler())
+
+	db, err := sql.Open(DefaultDriver, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	want := Neo4jError{
+		Code:           "Neo.ClientError.Statement.SyntaxError",
+		Message:        "Invalid input",
+		Classification: "ClientError",
+		Category:       "Statement",
+		Title:          "SyntaxError",
+	}
+	check := func(err error) {
+		t.Helper()
+		nerr, ok := err.(*Neo4jError)
+		if !ok {
+			t.Fatalf("wanted *Neo4jError, got %#v", err)
+		}
+		if *nerr != want {
+			t.Fatalf("wanted %#v, got %#v", want, *nerr)
+		}
+	}
+
+	_, err = db.Exec("FAIL")
+	check(err)
+	_, err = db.Query("FAIL")
+	check(err)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tx.Exec("FAIL")
+	check(err)
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The failures were acknowledged, so the connection can be reused.
+	var n int64
+	if err := db.QueryRow("RETURN $n", Map{"n": 1}).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,82 @@
+package bolt
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync/atomic"
+)
+
+var (
+	_ driver.SessionResetter = (*conn)(nil)
+	_ driver.Validator       = (*conn)(nil)
+	_ driver.Pinger          = (*conn)(nil)
+)
+
+// IsValid reports whether the connection can be reused: it isn't broken, it
+// isn't in a transaction, and every response to the messages sent on it has
+// been read. It implements driver.Validator.
+func (c *conn) IsValid() bool {
+	if c.bad || c.status != statusIdle {
+		return false
+	}
+	if c.dec != nil && !c.dec.More() {
+		return false
+	}
+	return atomic.LoadInt32(&c.pending) == 0
+}
+
+// ResetSession sends a RESET, clearing any state left on the server by the
+// connection's previous user. It implements driver.SessionResetter.
+func (c *conn) ResetSession(ctx context.Context) error {
+	if !c.IsValid() {
+		return driver.ErrBadConn
+	}
+	if err := c.roundTrip(ctx); err != nil {
+		if ctx.Err() == nil {
+			c.bad = true
+			return driver.ErrBadConn
+		}
+		return err
+	}
+	return nil
+}
+
+// Ping verifies the connection is alive with a round trip to the server. It
+// implements driver.Pinger.
+func (c *conn) Ping(ctx context.Context) error {
+	if c.bad {
+		return driver.ErrBadConn
+	}
+	if c.status != statusIdle {
+		// A RESET would end the transaction.
+		if _, err := c.exec(ctx, "RETURN 1", nil); err != nil {
+			if c.bad {
+				return driver.ErrBadConn
+			}
+			return err
+		}
+		return nil
+	}
+	if err := c.roundTrip(ctx); err != nil {
+		if ctx.Err() == nil {
+			c.bad = true
+			return driver.ErrBadConn
+		}
+		return err
+	}
+	return nil
+}
+
+// roundTrip sends a RESET and waits for its response. It's the cheapest
+// message the server answers.
+func (c *conn) roundTrip(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	finish := c.watchCancel(ctx)
+	err := c.reset()
+	if cerr := finish(); cerr != nil {
+		return cerr
+	}
+	return err
+}
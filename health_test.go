@@ -0,0 +1,134 @@
+package bolt
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/sermodigital/bolt/structures/messages"
+)
+
+func TestConn_IsValid(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+
+	dc, err := Open(srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dc.Close()
+	c := dc.(*conn)
+
+	if !c.IsValid() {
+		t.Fatal("wanted a new connection to be valid")
+	}
+	rows, err := c.QueryNeo("RETURN 1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.IsValid() {
+		t.Fatal("wanted a connection with unread responses to be invalid")
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !c.IsValid() {
+		t.Fatal("wanted the connection to be valid once the rows were closed")
+	}
+
+	if _, err := c.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	if c.IsValid() {
+		t.Fatal("wanted a connection in a transaction to be invalid")
+	}
+	if err := c.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	c.bad = true
+	if c.IsValid() {
+		t.Fatal("wanted a bad connection to be invalid")
+	}
+	c.bad = false
+}
+
+func TestConn_ResetSession(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.setHandler(echoHandler)
+
+	db, err := sql.Open(DefaultDriver, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	for i := 0; i < 2; i++ {
+		if _, err := db.Exec("RETURN 1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if resets := len(srv.messages(messages.ResetSignature)); resets != 1 {
+		t.Fatalf("wanted 1 RESET, got %d", resets)
+	}
+
+	// A connection that fails to reset is discarded.
+	srv.setHandler(func(sig uint8, fields []interface{}) []interface{} {
+		if sig == messages.ResetSignature {
+			return []interface{}{messages.Failure{Metadata: map[string]interface{}{
+				"code": "Neo.DatabaseError.General.UnknownError",
+			}}}
+		}
+		return echoHandler(sig, fields)
+	})
+	if _, err := db.Exec("RETURN 1"); err != nil {
+		t.Fatal(err)
+	}
+	srv.mu.Lock()
+	conns := srv.conns
+	srv.mu.Unlock()
+	if conns != 2 {
+		t.Fatalf("wanted the connection to be replaced, got %d dials", conns)
+	}
+}
+
+func TestConn_Ping(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+
+	dc, err := Open(srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dc.Close()
+	c := dc.(*conn)
+
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if resets := len(srv.messages(messages.ResetSignature)); resets != 1 {
+		t.Fatalf("wanted 1 RESET, got %d", resets)
+	}
+
+	// Pinging inside a transaction mustn't end it.
+	if _, err := c.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if resets := len(srv.messages(messages.ResetSignature)); resets != 1 {
+		t.Fatalf("wanted 1 RESET, got %d", resets)
+	}
+	if err := c.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.bad = true
+	if err := c.Ping(context.Background()); err != driver.ErrBadConn {
+		t.Fatalf("wanted driver.ErrBadConn, got %v", err)
+	}
+	c.bad = false
+}
@@ -0,0 +1,186 @@
+package bolt
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+
+	"github.com/sermodigital/bolt/structures/messages"
+)
+
+// ErrIgnored is returned for a pipelined statement the server did not run
+// because an earlier statement in the pipeline failed.
+var ErrIgnored = errors.New("bolt: statement ignored after an earlier failure")
+
+// Statement is a query and its parameters.
+type Statement struct {
+	Query  string
+	Params map[string]interface{}
+}
+
+// Pipeliner is implemented by connections that can pipeline statements.
+// Connections opened by this package, including those returned by a Pool,
+// implement it.
+type Pipeliner interface {
+	// Pipeline sends every statement to the server with a single write
+	// without waiting for any of them to complete, then returns their
+	// results in order. The connection cannot be used for anything else
+	// until the results are closed.
+	Pipeline(ctx context.Context, stmts ...Statement) (*PipelineResults, error)
+}
+
+var _ Pipeliner = (*conn)(nil)
+
+// PipelineResult is the result of a single pipelined statement.
+type PipelineResult struct {
+	Columns []string
+	Rows    [][]interface{}
+	Summary Summary
+
+	// Err is the statement's failure, if any. Once a statement fails the
+	// server ignores the rest of the pipeline and their Err is ErrIgnored.
+	Err error
+}
+
+// PipelineResults reads the results of pipelined statements.
+type PipelineResults struct {
+	conn   *conn
+	stmts  []Statement
+	next   int
+	failed bool
+	err    error
+	finish func() error
+
+	// Every auto-commit statement reports a bookmark, but only the last
+	// one is recorded once every result has been read.
+	bookmarks *Bookmarks
+	sent      []string
+	bookmark  string
+}
+
+// Pipeline implements Pipeliner.
+func (c *conn) Pipeline(ctx context.Context, stmts ...Statement) (*PipelineResults, error) {
+	if c.bad {
+		return nil, driver.ErrBadConn
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	md, err := c.runMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p := &PipelineResults{conn: c, stmts: stmts}
+	if c.status == statusIdle {
+		p.bookmarks, p.sent = c.bookmarks, c.sentBookmarks
+		c.bookmarks, c.sentBookmarks = nil, nil
+	}
+
+	msgs := make([]interface{}, 0, 2*len(stmts))
+	for _, s := range stmts {
+		msgs = append(msgs, c.runMessage(s.Query, s.Params, md), c.pullAllMessage())
+	}
+	finish := c.watchCancel(ctx)
+	if err := c.encodeAll(msgs...); err != nil {
+		c.bad = true
+		return nil, multi(err, finish())
+	}
+	p.finish = finish
+	return p, nil
+}
+
+// Next returns the result of the next statement. It returns io.EOF after the
+// last statement's result. Failures of individual statements are reported by
+// PipelineResult.Err; an error is only returned if the results can't be read.
+func (p *PipelineResults) Next() (*PipelineResult, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.next == len(p.stmts) {
+		p.err = p.done(io.EOF)
+		return nil, p.err
+	}
+
+	res := &PipelineResult{}
+	res.Summary.Query = p.stmts[p.next].Query
+	p.next++
+	if err := p.read(res); err != nil {
+		// Responses to the remaining statements may still be on the wire.
+		p.conn.bad = true
+		p.err = p.done(err)
+		return nil, p.err
+	}
+	if res.Summary.ServerInfo.Version == "" {
+		res.Summary.ServerInfo.Version = p.conn.server
+	}
+	return res, nil
+}
+
+// read reads the responses to a statement's RUN and PULL_ALL messages.
+func (p *PipelineResults) read(res *PipelineResult) error {
+	// The responses are decoded without acknowledging failures, which would
+	// consume the responses to the statements that follow.
+	for i := 0; i < 2; {
+		resp, err := p.conn.decode()
+		if err != nil {
+			return err
+		}
+		switch resp := resp.(type) {
+		case messages.Success:
+			if bm, ok := resp.Metadata["bookmark"].(string); ok {
+				p.bookmark = bm
+			}
+			res.Summary.parseSuccess(resp.Metadata)
+			if i == 0 {
+				res.Columns = parseCols(resp.Metadata)
+			}
+			i++
+		case messages.Record:
+			res.Rows = append(res.Rows, resp.Values)
+		case messages.Failure:
+			p.failed = true
+			if res.Err == nil {
+				res.Err = newNeo4jError(resp)
+			}
+			i++
+		case messages.Ignored:
+			if res.Err == nil {
+				res.Err = ErrIgnored
+			}
+			i++
+		default:
+			return UnrecognizedResponseErr{v: resp}
+		}
+	}
+	return nil
+}
+
+// Close discards the results that have not been read and acknowledges any
+// failures, leaving the connection ready for reuse.
+func (p *PipelineResults) Close() error {
+	for p.err == nil {
+		p.Next()
+	}
+	if p.err == io.EOF {
+		return nil
+	}
+	return p.err
+}
+
+// done stops watching the pipeline's context and acknowledges any failures
+// once every response has been read.
+func (p *PipelineResults) done(err error) error {
+	if p.bookmarks != nil && p.bookmark != "" {
+		p.bookmarks.replace(p.sent, p.bookmark)
+	}
+	if cerr := p.finish(); cerr != nil {
+		return cerr
+	}
+	if err == io.EOF && p.failed {
+		if rerr := p.conn.resolveFailure(); rerr != nil {
+			return rerr
+		}
+	}
+	return err
+}
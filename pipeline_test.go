@@ -0,0 +1,162 @@
+package bolt
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/sermodigital/bolt/structures/graph"
+	"github.com/sermodigital/bolt/structures/messages"
+)
+
+// failingHandler is like echoHandler but fails the query "FAIL", ignoring
+// every message after a failure until it's acknowledged. Bolt v1 transaction
+// queries succeed without returning records.
+func failingHandler() func(uint8, []interface{}) []interface{} {
+	failed := false
+	return func(sig uint8, fields []interface{}) []interface{} {
+		success := []interface{}{messages.Success{Metadata: map[string]interface{}{}}}
+		switch {
+		case sig == messages.AckFailureSignature, sig == messages.ResetSignature:
+			failed = false
+			return success
+		case failed:
+			return []interface{}{messages.Ignored{}}
+		case sig == messages.RunSignature:
+			switch fields[0] {
+			case "BEGIN", "COMMIT", "ROLLBACK":
+				return success
+			case "FAIL":
+				failed = true
+				return []interface{}{messages.Failure{Metadata: map[string]interface{}{
+					"code":    "Neo.ClientError.Statement.SyntaxError",
+					"message": "Invalid input",
+				}}}
+			}
+			return echoHandler(sig, fields)
+		default:
+			return echoHandler(sig, fields)
+		}
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.setHandler(failingHandler())
+
+	pool, err := OpenPool(srv.dsn(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	c, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	res, err := c.(Pipeliner).Pipeline(context.Background(),
+		Statement{Query: "RETURN $a", Params: map[string]interface{}{"a": int64(1)}},
+		Statement{Query: "RETURN $b", Params: map[string]interface{}{"b": "two"}},
+		Statement{Query: "FAIL"},
+		Statement{Query: "RETURN $c", Params: map[string]interface{}{"c": int64(3)}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		cols []string
+		rows [][]interface{}
+	}{
+		{[]string{"a"}, [][]interface{}{{int64(1)}}},
+		{[]string{"b"}, [][]interface{}{{"two"}}},
+	}
+	for i, w := range want {
+		r, err := res.Next()
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if r.Err != nil {
+			t.Fatalf("#%d: %v", i, r.Err)
+		}
+		if !reflect.DeepEqual(r.Columns, w.cols) || !reflect.DeepEqual(r.Rows, w.rows) {
+			t.Fatalf("#%d: wanted %v %v, got %v %v", i, w.cols, w.rows, r.Columns, r.Rows)
+		}
+	}
+	r, err := res.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Err == nil || r.Err == ErrIgnored {
+		t.Fatalf("wanted the statement to fail, got %v", r.Err)
+	}
+	r, err = res.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Err != ErrIgnored {
+		t.Fatalf("wanted ErrIgnored, got %v", r.Err)
+	}
+	if _, err := res.Next(); err != io.EOF {
+		t.Fatalf("wanted io.EOF, got %v", err)
+	}
+	if err := res.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The failure was acknowledged, so the connection can be reused.
+	res, err = c.(Pipeliner).Pipeline(context.Background(),
+		Statement{Query: "RETURN $a", Params: map[string]interface{}{"a": int64(1)}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err = res.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+	if err := res.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPipeline_BadResponse(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.setHandler(func(sig uint8, fields []interface{}) []interface{} {
+		if sig == messages.PullAllSignature {
+			return []interface{}{graph.Node{}}
+		}
+		return echoHandler(sig, fields)
+	})
+
+	c, err := OpenNeo(srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	res, err := c.(Pipeliner).Pipeline(context.Background(),
+		Statement{Query: "RETURN 1"},
+		Statement{Query: "RETURN 2"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := res.Next(); err == nil {
+		t.Fatal("wanted an error reading an unrecognized response")
+	}
+	// The second statement's responses were never read, so the connection
+	// must not be reused.
+	if _, err := c.(Pipeliner).Pipeline(context.Background()); err != driver.ErrBadConn {
+		t.Fatalf("wanted driver.ErrBadConn, got %v", err)
+	}
+}
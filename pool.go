@@ -0,0 +1,313 @@
+package bolt
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrPoolClosed is returned when a connection is requested from a closed Pool.
+var ErrPoolClosed = errors.New("bolt: pool is closed")
+
+// ErrPoolTimeout is returned when a connection could not be acquired from a
+// Pool within its acquisition timeout.
+var ErrPoolTimeout = errors.New("bolt: timed out waiting for a connection")
+
+// Pool is a Bolt-native connection pool. It is safe for concurrent use by
+// multiple goroutines.
+//
+// Connections acquired from a Pool are returned to it when closed.
+type Pool struct {
+	connector driver.Connector
+
+	// sem holds one token for every open connection, limiting the pool's
+	// size.
+	sem chan struct{}
+	// done is closed by Close to wake callers of Get waiting for a token.
+	done chan struct{}
+
+	mu          sync.Mutex
+	idle        []*pooledConn
+	maxIdle     int
+	maxLifetime time.Duration
+	checkAfter  time.Duration
+	timeout     time.Duration
+	closed      bool
+}
+
+// DefaultHealthCheckAfter is how long a pooled connection may sit idle before
+// it is verified with a RESET, unless changed with SetHealthCheckAfter.
+const DefaultHealthCheckAfter = 30 * time.Second
+
+// OpenPool calls DialOpenPool with a nil Dialer.
+func OpenPool(name string, max int) (*Pool, error) {
+	return DialOpenPool(nil, name, max)
+}
+
+// DialOpenPool creates a Pool that dials connections to name with d, or with
+// the Dialer configured by name's tls parameters if d is nil. No more than max
+// connections will be open at a time. The DSN is validated by
+// dialing an initial connection, which is kept idle in the pool. The routing
+// schemes aren't accepted.
+func DialOpenPool(d Dialer, name string, max int) (*Pool, error) {
+	if max <= 0 {
+		return nil, errors.New("bolt: pool size must be positive")
+	}
+	if isRouting(name) {
+		return nil, ErrRoutingUnsupported
+	}
+	connector, err := newConnector(d, name)
+	if err != nil {
+		return nil, err
+	}
+	p := &Pool{
+		connector:  connector,
+		sem:        make(chan struct{}, max),
+		done:       make(chan struct{}),
+		maxIdle:    max,
+		checkAfter: DefaultHealthCheckAfter,
+	}
+	c, err := p.Get(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Close(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SetMaxIdleConns sets the maximum number of connections kept in the idle
+// pool. If n <= 0, no idle connections are retained. It defaults to the
+// pool's size.
+func (p *Pool) SetMaxIdleConns(n int) {
+	if n < 0 {
+		n = 0
+	}
+	p.mu.Lock()
+	p.maxIdle = n
+	var extra []*pooledConn
+	if len(p.idle) > n {
+		extra = p.idle[n:]
+		p.idle = p.idle[:n]
+	}
+	p.mu.Unlock()
+	for _, pc := range extra {
+		pc.destroy()
+	}
+}
+
+// SetConnMaxLifetime sets the maximum amount of time a connection may be
+// reused. Expired connections are closed instead of being returned to the
+// pool. If d <= 0, connections are reused forever.
+func (p *Pool) SetConnMaxLifetime(d time.Duration) {
+	p.mu.Lock()
+	p.maxLifetime = d
+	p.mu.Unlock()
+}
+
+// SetHealthCheckAfter sets how long a connection may sit idle before it is
+// sent a RESET message to verify it is still healthy. If d <= 0, every idle
+// connection is checked before being handed out. It defaults to
+// DefaultHealthCheckAfter.
+func (p *Pool) SetHealthCheckAfter(d time.Duration) {
+	p.mu.Lock()
+	p.checkAfter = d
+	p.mu.Unlock()
+}
+
+// SetAcquireTimeout sets the maximum amount of time Get blocks waiting for a
+// connection when the pool is exhausted. If d <= 0, Get blocks until a
+// connection is available or its context is done.
+func (p *Pool) SetAcquireTimeout(d time.Duration) {
+	p.mu.Lock()
+	p.timeout = d
+	p.mu.Unlock()
+}
+
+// Get returns a connection from the pool, dialing a new one if no idle
+// connections are available. If the pool is at capacity Get blocks until a
+// connection is returned, the acquisition timeout elapses, or ctx is done.
+// The acquisition timeout and ctx also bound the health check of idle
+// connections.
+//
+// Closing the returned connection returns it to the pool.
+func (p *Pool) Get(ctx context.Context) (Conn, error) {
+	p.mu.Lock()
+	closed, timeout := p.closed, p.timeout
+	p.mu.Unlock()
+	if closed {
+		return nil, ErrPoolClosed
+	}
+
+	acquireCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		acquireCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	select {
+	case p.sem <- struct{}{}:
+	case <-p.done:
+		return nil, ErrPoolClosed
+	case <-acquireCtx.Done():
+		return nil, acquireErr(ctx)
+	}
+
+	// The pool may have been closed while we waited for a token.
+	p.mu.Lock()
+	closed = p.closed
+	p.mu.Unlock()
+	if closed {
+		<-p.sem
+		return nil, ErrPoolClosed
+	}
+
+	for {
+		pc, ok := p.popIdle()
+		if !ok {
+			break
+		}
+		if p.healthy(acquireCtx, pc) {
+			pc.released = false
+			return pc, nil
+		}
+		pc.destroy()
+		if acquireCtx.Err() != nil {
+			<-p.sem
+			return nil, acquireErr(ctx)
+		}
+	}
+
+	c, err := p.connector.Connect(ctx)
+	if err != nil {
+		<-p.sem
+		return nil, err
+	}
+	return &pooledConn{conn: c.(*conn), pool: p, created: time.Now()}, nil
+}
+
+// acquireErr returns the error Get returns when it gives up acquiring a
+// connection: ctx's error, or ErrPoolTimeout if the acquisition timeout
+// elapsed first.
+func acquireErr(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return ErrPoolTimeout
+}
+
+// popIdle removes the most recently used connection from the idle list.
+func (p *Pool) popIdle() (*pooledConn, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed || len(p.idle) == 0 {
+		return nil, false
+	}
+	pc := p.idle[len(p.idle)-1]
+	p.idle[len(p.idle)-1] = nil
+	p.idle = p.idle[:len(p.idle)-1]
+	return pc, true
+}
+
+// healthy reports whether pc can be handed out again. Connections idle for
+// longer than the health check threshold are verified with a RESET, which is
+// abandoned when ctx is done.
+func (p *Pool) healthy(ctx context.Context, pc *pooledConn) bool {
+	p.mu.Lock()
+	lifetime, checkAfter := p.maxLifetime, p.checkAfter
+	p.mu.Unlock()
+
+	if !pc.IsValid() || pc.expired(lifetime) {
+		return false
+	}
+	if time.Since(pc.returned) < checkAfter {
+		return true
+	}
+	if err := pc.roundTrip(ctx); err != nil {
+		pc.bad = true
+		return false
+	}
+	return true
+}
+
+// put returns pc to the pool, closing it if it cannot be reused.
+func (p *Pool) put(pc *pooledConn) error {
+	defer func() { <-p.sem }()
+
+	p.mu.Lock()
+	reuse := !p.closed && pc.IsValid() &&
+		!pc.expired(p.maxLifetime) && len(p.idle) < p.maxIdle
+	if reuse {
+		pc.returned = time.Now()
+		p.idle = append(p.idle, pc)
+	}
+	p.mu.Unlock()
+
+	if reuse {
+		return nil
+	}
+	return pc.destroy()
+}
+
+// Close closes the pool and all of its idle connections. Connections that are
+// in use are closed when they are returned.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	var errs []error
+	for _, pc := range idle {
+		if err := pc.destroy(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return multi(errs...)
+}
+
+// pooledConn is a conn that belongs to a Pool.
+type pooledConn struct {
+	*conn
+	pool     *Pool
+	created  time.Time
+	returned time.Time
+	released bool
+}
+
+// expired reports whether pc has outlived lifetime.
+func (pc *pooledConn) expired(lifetime time.Duration) bool {
+	return lifetime > 0 && time.Since(pc.created) >= lifetime
+}
+
+// destroy closes the underlying connection. Bad connections may have already
+// been closed, so only healthy connections report errors.
+func (pc *pooledConn) destroy() error {
+	if pc.bad {
+		pc.conn.conn.Close()
+		return nil
+	}
+	return pc.conn.Close()
+}
+
+// Close returns the connection to its Pool. It helps implement driver.Conn.
+func (pc *pooledConn) Close() error {
+	if pc.released {
+		return nil
+	}
+	pc.released = true
+	return pc.pool.put(pc)
+}
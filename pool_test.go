@@ -0,0 +1,242 @@
+package bolt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sermodigital/bolt/structures/messages"
+)
+
+func TestPool_Reuse(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+
+	pool, err := OpenPool(srv.dsn(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	for i := 0; i < 5; i++ {
+		c, err := pool.Get(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	srv.mu.Lock()
+	conns := srv.conns
+	srv.mu.Unlock()
+	if conns != 1 {
+		t.Fatalf("wanted 1 connection to be dialed, got %d", conns)
+	}
+
+	if resets := countResets(srv); resets != 0 {
+		t.Fatalf("wanted recently used connections to skip health checks, got %d", resets)
+	}
+
+	pool.SetHealthCheckAfter(0)
+	c, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if resets := countResets(srv); resets != 1 {
+		t.Fatalf("wanted 1 health check, got %d", resets)
+	}
+}
+
+// countResets returns the number of RESET messages srv has received.
+func countResets(srv *stubServer) int {
+	var resets int
+	for _, sig := range srv.received() {
+		if sig == messages.ResetSignature {
+			resets++
+		}
+	}
+	return resets
+}
+
+func TestPool_AcquireTimeout(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+
+	pool, err := OpenPool(srv.dsn(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	pool.SetAcquireTimeout(10 * time.Millisecond)
+
+	c, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.Get(context.Background()); err != ErrPoolTimeout {
+		t.Fatalf("wanted ErrPoolTimeout, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pool.SetAcquireTimeout(0)
+	if _, err := pool.Get(ctx); err != context.Canceled {
+		t.Fatalf("wanted context.Canceled, got %v", err)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	c, err = pool.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPool_MaxLifetime(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+
+	pool, err := OpenPool(srv.dsn(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	pool.SetConnMaxLifetime(time.Nanosecond)
+
+	c, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.mu.Lock()
+	conns := srv.conns
+	srv.mu.Unlock()
+	if conns != 2 {
+		t.Fatalf("wanted expired connection to be redialed, got %d dials", conns)
+	}
+}
+
+func TestPool_HealthCheckTimeout(t *testing.T) {
+	defer func(d time.Duration) { interruptTimeout = d }(interruptTimeout)
+	interruptTimeout = 10 * time.Millisecond
+
+	srv := newStubServer(t)
+	defer srv.Close()
+
+	pool, err := OpenPool(srv.dsn(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	pool.SetHealthCheckAfter(0)
+	pool.SetAcquireTimeout(10 * time.Millisecond)
+
+	// The idle connection never answers its health check.
+	srv.setHandler(stallHandler(false))
+	errc := make(chan error, 1)
+	go func() {
+		c, err := pool.Get(context.Background())
+		if err == nil {
+			c.Close()
+		}
+		errc <- err
+	}()
+	select {
+	case err := <-errc:
+		if err != ErrPoolTimeout {
+			t.Fatalf("wanted ErrPoolTimeout, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Get blocked on an unresponsive idle connection")
+	}
+
+	// The unresponsive connection was discarded.
+	srv.setHandler(v3Handler)
+	c, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	srv.mu.Lock()
+	conns := srv.conns
+	srv.mu.Unlock()
+	if conns != 2 {
+		t.Fatalf("wanted the connection to be replaced, got %d dials", conns)
+	}
+}
+
+func TestPool_Closed(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+
+	pool, err := OpenPool(srv.dsn(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.Get(context.Background()); err != ErrPoolClosed {
+		t.Fatalf("wanted ErrPoolClosed, got %v", err)
+	}
+}
+
+func TestPool_CloseWhileWaiting(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+
+	pool, err := OpenPool(srv.dsn(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		c, err := pool.Get(context.Background())
+		if err == nil {
+			c.Close()
+		}
+		errc <- err
+	}()
+	// Give Get time to block on the exhausted pool.
+	time.Sleep(10 * time.Millisecond)
+	if err := pool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-errc:
+		if err != ErrPoolClosed {
+			t.Fatalf("wanted ErrPoolClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Get wasn't woken by Close")
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	srv.mu.Lock()
+	conns := srv.conns
+	srv.mu.Unlock()
+	if conns != 1 {
+		t.Fatalf("wanted no connections to be dialed after Close, got %d dials", conns)
+	}
+}
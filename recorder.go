@@ -2,6 +2,7 @@ package bolt
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"database/sql/driver"
 	"encoding/hex"
@@ -56,14 +57,22 @@ func (r *Recorder) Open(name string) (driver.Conn, error) {
 		if err != nil {
 			return nil, err
 		}
-		return newConn(r, nil)
+		return newConn(r, Config{})
 	}
-	conn, v, err := open(&dialer{}, name)
+	v, err := parseDSN(name)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := newConfig(v)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dial(context.Background(), &dialer{}, cfg)
 	if err != nil {
 		return nil, err
 	}
 	r.Conn = conn
-	return newConn(r, v)
+	return newConn(r, cfg)
 }
 
 func (r *Recorder) lastEvent() *Event {
@@ -0,0 +1,124 @@
+package bolt
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy determines how ExecuteWrite and ExecuteRead retry transactions
+// that fail with retryable errors.
+type RetryPolicy struct {
+	// MaxRetryTime is the maximum amount of time spent retrying a
+	// transaction, measured from the start of the first attempt.
+	MaxRetryTime time.Duration
+
+	// InitialDelay is the delay before the first retry.
+	InitialDelay time.Duration
+
+	// Multiplier is the factor the delay grows by after each retry.
+	Multiplier float64
+
+	// Jitter is the fraction of each delay that is randomized, e.g. 0.2
+	// randomizes each delay by up to 20% in either direction.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is the RetryPolicy used by ExecuteWrite and
+// ExecuteRead.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetryTime: 30 * time.Second,
+	InitialDelay: time.Second,
+	Multiplier:   2,
+	Jitter:       0.2,
+}
+
+// ExecuteWrite calls DefaultRetryPolicy.ExecuteWrite.
+func ExecuteWrite(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
+	return DefaultRetryPolicy.ExecuteWrite(ctx, db, fn)
+}
+
+// ExecuteRead calls DefaultRetryPolicy.ExecuteRead.
+func ExecuteRead(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
+	return DefaultRetryPolicy.ExecuteRead(ctx, db, fn)
+}
+
+// ExecuteWrite runs fn inside of a transaction and commits it. If fn returns
+// an error the transaction is rolled back.
+//
+// If the transaction fails with a retryable error it's retried with
+// exponential backoff until it succeeds, fails with an error that isn't
+// retryable, or MaxRetryTime elapses, in which case the last error is
+// returned. If ctx is done while waiting to retry, ctx.Err() is returned. fn
+// may be called multiple times, so it should not have side effects outside of
+// the transaction.
+//
+// Errors are retryable if they're a *Neo4jError whose Retryable method
+// reports true or driver.ErrBadConn.
+func (p RetryPolicy) ExecuteWrite(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
+	return p.execute(ctx, db, &sql.TxOptions{}, fn)
+}
+
+// ExecuteRead is like ExecuteWrite but begins read-only transactions.
+func (p RetryPolicy) ExecuteRead(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
+	return p.execute(ctx, db, &sql.TxOptions{ReadOnly: true}, fn)
+}
+
+// execute is the common implementation of ExecuteWrite and ExecuteRead.
+func (p RetryPolicy) execute(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+	start := time.Now()
+	delay := p.InitialDelay
+	for {
+		err := runTx(ctx, db, opts, fn)
+		if err == nil || !retryable(err) {
+			return err
+		}
+
+		wait := p.jitter(delay)
+		if time.Since(start)+wait > p.MaxRetryTime {
+			return err
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+		delay = time.Duration(float64(delay) * p.Multiplier)
+	}
+}
+
+// jitter randomizes d by up to p.Jitter in either direction.
+func (p RetryPolicy) jitter(d time.Duration) time.Duration {
+	if p.Jitter <= 0 {
+		return d
+	}
+	return d + time.Duration((2*rand.Float64()-1)*p.Jitter*float64(d))
+}
+
+// runTx runs fn in a single transaction.
+func runTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// retryable reports whether a transaction that failed with err should be
+// retried.
+func retryable(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	var nerr *Neo4jError
+	return errors.As(err, &nerr) && nerr.Retryable()
+}
@@ -0,0 +1,157 @@
+package bolt
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sermodigital/bolt/structures/messages"
+)
+
+// transientHandler fails the first n transactions that run the query "WRITE"
+// with code, ignoring messages after a failure until the RESET.
+func transientHandler(n int, code string) func(uint8, []interface{}) []interface{} {
+	failed := false
+	return func(sig uint8, fields []interface{}) []interface{} {
+		success := []interface{}{messages.Success{Metadata: map[string]interface{}{}}}
+		switch {
+		case sig == messages.ResetSignature:
+			failed = false
+			return success
+		case failed:
+			return []interface{}{messages.Ignored{}}
+		case sig == messages.RunSignature && fields[0] == "WRITE" && n > 0:
+			n--
+			failed = true
+			return []interface{}{messages.Failure{Metadata: map[string]interface{}{
+				"code":    code,
+				"message": "failed",
+			}}}
+		default:
+			return echoHandler(sig, fields)
+		}
+	}
+}
+
+func newRetryDB(t *testing.T, srv *stubServer) *sql.DB {
+	db, err := sql.Open(DefaultDriver, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	return db
+}
+
+var testRetryPolicy = RetryPolicy{
+	MaxRetryTime: time.Second,
+	InitialDelay: time.Millisecond,
+	Multiplier:   2,
+	Jitter:       0.2,
+}
+
+func TestExecuteWrite_Retry(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.version = version3_0
+	srv.setHandler(transientHandler(2, "Neo.TransientError.Transaction.DeadlockDetected"))
+	db := newRetryDB(t, srv)
+	defer db.Close()
+
+	var calls int
+	err := testRetryPolicy.ExecuteWrite(context.Background(), db, func(tx *sql.Tx) error {
+		calls++
+		_, err := tx.Exec("WRITE")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Fatalf("wanted 3 attempts, got %d", calls)
+	}
+	if n := len(srv.messages(messages.CommitSignature)); n != 1 {
+		t.Fatalf("wanted 1 commit, got %d", n)
+	}
+}
+
+func TestExecuteWrite_RetryWrapped(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.version = version3_0
+	srv.setHandler(transientHandler(1, "Neo.TransientError.Transaction.DeadlockDetected"))
+	db := newRetryDB(t, srv)
+	defer db.Close()
+
+	var calls int
+	err := testRetryPolicy.ExecuteWrite(context.Background(), db, func(tx *sql.Tx) error {
+		calls++
+		if _, err := tx.Exec("WRITE"); err != nil {
+			return fmt.Errorf("writing: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("wanted 2 attempts, got %d", calls)
+	}
+}
+
+func TestExecuteWrite_NotRetryable(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.version = version3_0
+	srv.setHandler(transientHandler(1, "Neo.ClientError.Statement.SyntaxError"))
+	db := newRetryDB(t, srv)
+	defer db.Close()
+
+	var calls int
+	err := testRetryPolicy.ExecuteWrite(context.Background(), db, func(tx *sql.Tx) error {
+		calls++
+		_, err := tx.Exec("WRITE")
+		return err
+	})
+	if nerr, ok := err.(*Neo4jError); !ok || nerr.Retryable() {
+		t.Fatalf("wanted a non-retryable *Neo4jError, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("wanted 1 attempt, got %d", calls)
+	}
+}
+
+func TestExecuteWrite_MaxRetryTime(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.version = version3_0
+	srv.setHandler(transientHandler(1000, "Neo.TransientError.General.DatabaseUnavailable"))
+	db := newRetryDB(t, srv)
+	defer db.Close()
+
+	p := testRetryPolicy
+	p.MaxRetryTime = 20 * time.Millisecond
+	start := time.Now()
+	err := p.ExecuteWrite(context.Background(), db, func(tx *sql.Tx) error {
+		_, err := tx.Exec("WRITE")
+		return err
+	})
+	if nerr, ok := err.(*Neo4jError); !ok || !nerr.Retryable() {
+		t.Fatalf("wanted a retryable *Neo4jError, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("retried for %s", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p.MaxRetryTime = time.Minute
+	err = p.ExecuteRead(ctx, db, func(tx *sql.Tx) error {
+		_, err := tx.Exec("WRITE")
+		return err
+	})
+	if err != context.Canceled {
+		t.Fatalf("wanted context.Canceled, got %v", err)
+	}
+}
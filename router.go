@@ -0,0 +1,532 @@
+package bolt
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoServers is returned when the routing table of a cluster doesn't list
+// any reachable server for the access mode of a transaction.
+var ErrNoServers = errors.New("bolt: no servers available for the access mode")
+
+// ErrRoutingUnsupported is returned when a URI with one of the routing
+// schemes is passed to a function that returns a Conn or a Pool. Routed
+// connections can only be used through database/sql.
+var ErrRoutingUnsupported = errors.New("bolt: neo4j:// URIs can only be used with database/sql")
+
+// errMalformedTable is returned when a routing table can't be parsed.
+var errMalformedTable = errors.New("bolt: malformed routing table")
+
+// routingTable lists the members of a cluster by role.
+type routingTable struct {
+	routers []string
+	readers []string
+	writers []string
+	expires time.Time
+}
+
+// servers returns the servers suited to mode.
+func (t *routingTable) servers(mode AccessMode) []string {
+	if mode == ReadAccess {
+		return t.readers
+	}
+	return t.writers
+}
+
+// router keeps the routing table of a cluster up to date and dials its
+// members.
+type router struct {
+	cfg    Config
+	dialer Dialer
+	seed   string // the address the cluster was first contacted at
+
+	// now returns the current time. It's replaced by tests.
+	now func() time.Time
+
+	// next is used to spread connections across servers. It must be
+	// accessed atomically.
+	next uint32
+
+	// mu is held while the table is read or refreshed.
+	mu    sync.Mutex
+	table routingTable
+}
+
+func newRouter(cfg Config, d Dialer) *router {
+	return &router{
+		cfg:    cfg,
+		dialer: d,
+		seed:   net.JoinHostPort(cfg.Host, cfg.Port),
+		now:    time.Now,
+	}
+}
+
+// servers returns the servers suited to mode, refreshing the routing table
+// if it has expired or doesn't list any.
+func (r *router) servers(ctx context.Context, mode AccessMode) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.now().Before(r.table.expires) && len(r.table.servers(mode)) > 0 {
+		return append([]string(nil), r.table.servers(mode)...), nil
+	}
+	if err := r.refresh(ctx); err != nil {
+		return nil, err
+	}
+	servers := r.table.servers(mode)
+	if len(servers) == 0 {
+		return nil, ErrNoServers
+	}
+	return append([]string(nil), servers...), nil
+}
+
+// refresh fetches a new routing table from the known routers, falling back
+// to the seed address. Routers that can't be reached are forgotten. r.mu must
+// be held.
+func (r *router) refresh(ctx context.Context) error {
+	routers := append([]string(nil), r.table.routers...)
+	if !contains(routers, r.seed) {
+		routers = append(routers, r.seed)
+	}
+	var lastErr error
+	for _, addr := range routers {
+		table, err := r.fetch(ctx, addr)
+		if err == nil {
+			r.table = table
+			return nil
+		}
+		if cerr := ctx.Err(); cerr != nil {
+			return cerr
+		}
+		lastErr = err
+		r.table.routers = remove(r.table.routers, addr)
+	}
+	return fmt.Errorf("bolt: could not fetch a routing table: %v", lastErr)
+}
+
+// fetch retrieves the routing table from the router at addr.
+func (r *router) fetch(ctx context.Context, addr string) (routingTable, error) {
+	c, err := r.dial(ctx, addr)
+	if err != nil {
+		return routingTable{}, err
+	}
+	defer c.Close()
+
+	// The caller's bookmarks, transaction configuration and access mode
+	// apply to its own queries, not to this one.
+	ctx = detachedContext{ctx}
+	query := "CALL dbms.cluster.routing.getRoutingTable($context)"
+	params := map[string]interface{}{
+		"context": map[string]interface{}{"address": r.seed},
+	}
+	if c.version.major() >= 4 {
+		// As of Neo4j 4.0 the table is per database and is fetched from
+		// the system database.
+		query = "CALL dbms.routing.getRoutingTable($context, $database)"
+		params["database"] = nil
+		if r.cfg.Database != "" {
+			params["database"] = r.cfg.Database
+		}
+		ctx = WithDatabase(ctx, "system")
+	}
+	rows, err := c.query(ctx, query, params)
+	if err != nil {
+		return routingTable{}, err
+	}
+	data, _, err := rows.All()
+	if err != nil {
+		return routingTable{}, err
+	}
+	return parseRoutingTable(rows.Columns(), data, r.now())
+}
+
+// detachedContext has the deadline and cancellation of the Context it embeds
+// but none of its values.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Value(interface{}) interface{} { return nil }
+
+// parseRoutingTable parses the result of the getRoutingTable procedures,
+// which is a single row holding the table's time to live in seconds and its
+// servers by role.
+func parseRoutingTable(cols []string, data [][]interface{}, now time.Time) (routingTable, error) {
+	if len(data) != 1 || len(data[0]) != len(cols) {
+		return routingTable{}, errMalformedTable
+	}
+	var (
+		t       routingTable
+		ttl     int64
+		servers []interface{}
+		ok      bool
+	)
+	for i, col := range cols {
+		switch col {
+		case "ttl":
+			ttl, ok = data[0][i].(int64)
+		case "servers":
+			servers, ok = data[0][i].([]interface{})
+		default:
+			continue
+		}
+		if !ok {
+			return routingTable{}, errMalformedTable
+		}
+	}
+	for _, s := range servers {
+		s, ok := s.(map[string]interface{})
+		if !ok {
+			return routingTable{}, errMalformedTable
+		}
+		addrs, _ := s["addresses"].([]interface{})
+		for _, addr := range addrs {
+			addr, ok := addr.(string)
+			if !ok {
+				return routingTable{}, errMalformedTable
+			}
+			switch s["role"] {
+			case "ROUTE":
+				t.routers = append(t.routers, addr)
+			case "READ":
+				t.readers = append(t.readers, addr)
+			case "WRITE":
+				t.writers = append(t.writers, addr)
+			}
+		}
+	}
+	if len(t.routers) == 0 {
+		return routingTable{}, errMalformedTable
+	}
+	t.expires = now.Add(time.Duration(ttl) * time.Second)
+	return t, nil
+}
+
+// acquire opens a connection to a server suited to mode, forgetting the
+// servers that can't be reached.
+func (r *router) acquire(ctx context.Context, mode AccessMode) (*conn, string, error) {
+	var lastErr error
+	// If every server fails the next call to servers refreshes the table,
+	// so try twice.
+	for i := 0; i < 2; i++ {
+		servers, err := r.servers(ctx, mode)
+		if err != nil {
+			return nil, "", err
+		}
+		start := int(atomic.AddUint32(&r.next, 1))
+		for j := range servers {
+			addr := servers[(start+j)%len(servers)]
+			c, err := r.dial(ctx, addr)
+			if err == nil {
+				return c, addr, nil
+			}
+			if cerr := ctx.Err(); cerr != nil {
+				return nil, "", cerr
+			}
+			lastErr = err
+			r.forget(addr)
+		}
+	}
+	return nil, "", lastErr
+}
+
+// dial opens a connection to addr.
+func (r *router) dial(ctx context.Context, addr string) (*conn, error) {
+	cfg := r.cfg
+	var err error
+	cfg.Host, cfg.Port, err = net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewConnector(cfg, r.dialer).Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.(*conn), nil
+}
+
+// forget removes a server that failed from the routing table.
+func (r *router) forget(addr string) {
+	r.mu.Lock()
+	r.table.routers = remove(r.table.routers, addr)
+	r.table.readers = remove(r.table.readers, addr)
+	r.table.writers = remove(r.table.writers, addr)
+	r.mu.Unlock()
+}
+
+// forgetWriter removes a server that is no longer the cluster's leader from
+// the table's writers.
+func (r *router) forgetWriter(addr string) {
+	r.mu.Lock()
+	r.table.writers = remove(r.table.writers, addr)
+	r.mu.Unlock()
+}
+
+func remove(list []string, s string) []string {
+	out := list[:0:0]
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// RoutingConnector opens connections to a Neo4j cluster. Each connection
+// routes read-only transactions, and queries run with a context selecting
+// ReadAccess, to the cluster's readers and everything else to its writers.
+// The cluster's routing table is shared by the connections and is refreshed
+// once its time to live expires or a writer loses its leadership.
+//
+// RoutingConnector implements driver.Connector and can be passed to
+// sql.OpenDB. The connections it opens do not implement Conn or Pipeliner.
+type RoutingConnector struct {
+	r *router
+}
+
+var _ driver.Connector = (*RoutingConnector)(nil)
+
+// NewRoutingConnector returns a RoutingConnector that fetches the routing
+// table from the cluster member at cfg's host and port and connects to the
+// cluster's members with cfg and d. If d is nil the default, non-TLS, Dialer
+// is used.
+func NewRoutingConnector(cfg Config, d Dialer) *RoutingConnector {
+	if cfg.Host == "" {
+		cfg.Host = DefaultHost
+	}
+	if cfg.Port == "" {
+		cfg.Port = DefaultPort
+	}
+	if d == nil {
+		d = &dialer{}
+	}
+	return &RoutingConnector{r: newRouter(cfg, d)}
+}
+
+// Connect returns a connection to the cluster. Connections to its members
+// are opened once they're first needed. It implements driver.Connector.
+func (c *RoutingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	// Make sure the cluster can be reached.
+	if _, err := c.r.servers(ctx, ReadAccess); err != nil && err != ErrNoServers {
+		return nil, err
+	}
+	return &routingConn{r: c.r}, nil
+}
+
+// Driver implements driver.Connector.
+func (c *RoutingConnector) Driver() driver.Driver {
+	return &drv{}
+}
+
+// routingConn is a connection to a cluster. It holds a connection to a
+// server for each access mode.
+type routingConn struct {
+	r     *router
+	conns [2]*conn // by AccessMode
+	addrs [2]string
+	stale [2]bool // whether the server lost its leadership
+}
+
+var (
+	_ driver.Conn               = (*routingConn)(nil)
+	_ driver.QueryerContext     = (*routingConn)(nil)
+	_ driver.ExecerContext      = (*routingConn)(nil)
+	_ driver.ConnBeginTx        = (*routingConn)(nil)
+	_ driver.ConnPrepareContext = (*routingConn)(nil)
+	_ driver.NamedValueChecker  = (*routingConn)(nil)
+	_ driver.SessionResetter    = (*routingConn)(nil)
+	_ driver.Validator          = (*routingConn)(nil)
+	_ driver.Pinger             = (*routingConn)(nil)
+)
+
+// pick returns the connection for mode. Inside a transaction it returns the
+// connection running the transaction.
+func (rc *routingConn) pick(ctx context.Context, mode AccessMode) (AccessMode, *conn, error) {
+	for m, c := range rc.conns {
+		if c != nil && c.status != statusIdle {
+			return AccessMode(m), c, nil
+		}
+	}
+	if c := rc.conns[mode]; c != nil {
+		if c.bad {
+			return mode, nil, driver.ErrBadConn
+		}
+		return mode, c, nil
+	}
+	c, addr, err := rc.r.acquire(ctx, mode)
+	if err != nil {
+		return mode, nil, err
+	}
+	rc.conns[mode], rc.addrs[mode], rc.stale[mode] = c, addr, false
+	return mode, c, nil
+}
+
+// check updates the routing table after an operation on the connection for
+// mode failed with err.
+func (rc *routingConn) check(mode AccessMode, err error) error {
+	c := rc.conns[mode]
+	if err == nil || c == nil {
+		return err
+	}
+	var nerr *Neo4jError
+	if errors.As(err, &nerr) {
+		switch nerr.Code {
+		case "Neo.ClientError.Cluster.NotALeader",
+			"Neo.ClientError.General.ForbiddenOnReadOnlyDatabase":
+			// Writes have to go to the new leader.
+			rc.r.forgetWriter(rc.addrs[mode])
+			rc.stale[mode] = true
+			rc.release(mode)
+		}
+		return err
+	}
+	var neterr net.Error
+	if c.bad && !errors.Is(err, driver.ErrBadConn) && errors.As(err, &neterr) {
+		rc.r.forget(rc.addrs[mode])
+	}
+	return err
+}
+
+// release closes the connection for mode if its server lost its leadership,
+// unless it's still running a transaction.
+func (rc *routingConn) release(mode AccessMode) {
+	if c := rc.conns[mode]; c != nil && rc.stale[mode] && c.status == statusIdle {
+		c.Close()
+		rc.conns[mode] = nil
+		rc.stale[mode] = false
+	}
+}
+
+// Prepare prepares a query. It helps implement driver.Conn.
+func (rc *routingConn) Prepare(query string) (driver.Stmt, error) {
+	return rc.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext implements driver.ConnPrepareContext.
+func (rc *routingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	mode, c, err := rc.pick(ctx, accessModeFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := c.PrepareContext(ctx, query)
+	return stmt, rc.check(mode, err)
+}
+
+// Close closes the connections to the cluster's members. It helps implement
+// driver.Conn.
+func (rc *routingConn) Close() error {
+	var err error
+	for i, c := range rc.conns {
+		if c == nil {
+			continue
+		}
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+		rc.conns[i] = nil
+	}
+	return err
+}
+
+// Begin begins a new transaction. It helps implement driver.Conn.
+func (rc *routingConn) Begin() (driver.Tx, error) {
+	return rc.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx implements driver.ConnBeginTx.
+func (rc *routingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	mode := accessModeFromContext(ctx)
+	if opts.ReadOnly {
+		mode = ReadAccess
+	}
+	mode, c, err := rc.pick(ctx, mode)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.BeginTx(ctx, opts); err != nil {
+		return nil, rc.check(mode, err)
+	}
+	return &routingTx{rc: rc, mode: mode}, nil
+}
+
+// QueryContext implements driver.QueryerContext.
+func (rc *routingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	mode, c, err := rc.pick(ctx, accessModeFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := c.QueryContext(ctx, query, args)
+	return rows, rc.check(mode, err)
+}
+
+// ExecContext implements driver.ExecerContext.
+func (rc *routingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	mode, c, err := rc.pick(ctx, accessModeFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.ExecContext(ctx, query, args)
+	return res, rc.check(mode, err)
+}
+
+// CheckNamedValue implements driver.NamedValueChecker.
+func (rc *routingConn) CheckNamedValue(v *driver.NamedValue) error {
+	return checkNamedValue(v)
+}
+
+// ResetSession implements driver.SessionResetter.
+func (rc *routingConn) ResetSession(ctx context.Context) error {
+	for _, c := range rc.conns {
+		if c == nil {
+			continue
+		}
+		if err := c.ResetSession(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsValid implements driver.Validator.
+func (rc *routingConn) IsValid() bool {
+	for _, c := range rc.conns {
+		if c != nil && !c.IsValid() {
+			return false
+		}
+	}
+	return true
+}
+
+// Ping implements driver.Pinger.
+func (rc *routingConn) Ping(ctx context.Context) error {
+	mode, c, err := rc.pick(ctx, accessModeFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	return rc.check(mode, c.Ping(ctx))
+}
+
+// routingTx is a transaction on a routingConn.
+type routingTx struct {
+	rc   *routingConn
+	mode AccessMode
+}
+
+// Commit implements driver.Tx.
+func (tx *routingTx) Commit() error {
+	err := tx.rc.check(tx.mode, tx.rc.conns[tx.mode].Commit())
+	tx.rc.release(tx.mode)
+	return err
+}
+
+// Rollback implements driver.Tx.
+func (tx *routingTx) Rollback() error {
+	err := tx.rc.check(tx.mode, tx.rc.conns[tx.mode].Rollback())
+	tx.rc.release(tx.mode)
+	return err
+}
@@ -0,0 +1,349 @@
+package bolt
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sermodigital/bolt/structures/messages"
+)
+
+// routerHandler answers the getRoutingTable procedures with the table
+// returned by next, which is called once per request.
+func routerHandler(next func() (readers, writers []string)) func(uint8, []interface{}) []interface{} {
+	var (
+		mu   sync.Mutex
+		last string // the last query run
+	)
+	return func(sig uint8, fields []interface{}) []interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		switch sig {
+		case messages.RunSignature:
+			last, _ = fields[0].(string)
+			if !strings.Contains(last, "getRoutingTable") {
+				break
+			}
+			params := fields[1].(map[string]interface{})
+			ctx := params["context"].(map[string]interface{})
+			readers, writers := next()
+			role := func(role string, addrs ...string) map[string]interface{} {
+				list := make([]interface{}, len(addrs))
+				for i, addr := range addrs {
+					list[i] = addr
+				}
+				return map[string]interface{}{"role": role, "addresses": list}
+			}
+			servers := []interface{}{
+				role("ROUTE", ctx["address"].(string)),
+				role("READ", readers...),
+				role("WRITE", writers...),
+			}
+			return []interface{}{
+				messages.Success{Metadata: map[string]interface{}{
+					"fields": []interface{}{"ttl", "servers"},
+				}},
+				messages.Record{Values: []interface{}{int64(300), servers}},
+			}
+		}
+		return []interface{}{messages.Success{Metadata: map[string]interface{}{}}}
+	}
+}
+
+// routingRequests returns the number of routing tables requested from srv.
+func routingRequests(srv *stubServer) int {
+	var n int
+	for _, msg := range srv.messages(messages.RunSignature) {
+		if strings.Contains(msg.fields[0].(string), "getRoutingTable") {
+			n++
+		}
+	}
+	return n
+}
+
+// ran reports whether srv received query.
+func ran(srv *stubServer, query string) bool {
+	for _, msg := range srv.messages(messages.RunSignature) {
+		if msg.fields[0] == query {
+			return true
+		}
+	}
+	return false
+}
+
+func addr(srv *stubServer) string {
+	return srv.ln.Addr().String()
+}
+
+func TestRouting(t *testing.T) {
+	writer, reader, rt := newStubServer(t), newStubServer(t), newStubServer(t)
+	defer writer.Close()
+	defer reader.Close()
+	defer rt.Close()
+	rt.setHandler(routerHandler(func() ([]string, []string) {
+		return []string{addr(reader)}, []string{addr(writer)}
+	}))
+
+	db, err := sql.Open(DefaultDriver, RoutingScheme+addr(rt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE ()"); err != nil {
+		t.Fatal(err)
+	}
+	if !ran(writer, "CREATE ()") {
+		t.Fatal("wanted the write to run on the writer")
+	}
+
+	ctx := WithAccessMode(context.Background(), ReadAccess)
+	if _, err := db.ExecContext(ctx, "MATCH (n) RETURN n"); err != nil {
+		t.Fatal(err)
+	}
+	if !ran(reader, "MATCH (n) RETURN n") {
+		t.Fatal("wanted the read to run on the reader")
+	}
+
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("MATCH (n) RETURN count(n)"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if !ran(reader, "BEGIN") || !ran(reader, "MATCH (n) RETURN count(n)") {
+		t.Fatal("wanted the read-only transaction to run on the reader")
+	}
+
+	if n := routingRequests(rt); n != 1 {
+		t.Fatalf("wanted 1 routing table request, got %d", n)
+	}
+}
+
+func TestRouting_Bookmarks(t *testing.T) {
+	writer, rt := newStubServer(t), newStubServer(t)
+	defer writer.Close()
+	defer rt.Close()
+	rt.version = version3_0
+	writer.version = version3_0
+	writer.setHandler(v3Handler)
+	route := routerHandler(func() ([]string, []string) {
+		return nil, []string{addr(writer)}
+	})
+	rt.setHandler(func(sig uint8, fields []interface{}) []interface{} {
+		if sig == messages.PullAllSignature {
+			return []interface{}{messages.Success{Metadata: map[string]interface{}{
+				"bookmark": "system:99",
+			}}}
+		}
+		return route(sig, fields)
+	})
+
+	db, err := sql.Open(DefaultDriver, RoutingScheme+addr(rt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	bm := NewBookmarks("user:write:42")
+	ctx := WithTxTimeout(WithBookmarks(context.Background(), bm), time.Second)
+	if _, err := db.ExecContext(ctx, "CREATE ()"); err != nil {
+		t.Fatal(err)
+	}
+	for _, run := range rt.messages(messages.RunSignature) {
+		md := run.fields[2].(map[string]interface{})
+		if len(md) != 0 {
+			t.Fatalf("wanted the routing query to be sent without metadata, got %v", md)
+		}
+	}
+	if want := []string{"user:write:42"}; !reflect.DeepEqual(bm.Bookmarks(), want) {
+		t.Fatalf("wanted bookmarks %v, got %v", want, bm.Bookmarks())
+	}
+}
+
+func TestRouting_NotALeader(t *testing.T) {
+	old, leader, rt := newStubServer(t), newStubServer(t), newStubServer(t)
+	defer old.Close()
+	defer leader.Close()
+	defer rt.Close()
+	old.setHandler(func(sig uint8, fields []interface{}) []interface{} {
+		switch sig {
+		case messages.RunSignature:
+			return []interface{}{messages.Failure{Metadata: map[string]interface{}{
+				"code":    "Neo.ClientError.Cluster.NotALeader",
+				"message": "No write operations are allowed on this database.",
+			}}}
+		case messages.PullAllSignature:
+			return []interface{}{messages.Ignored{}}
+		default:
+			return []interface{}{messages.Success{Metadata: map[string]interface{}{}}}
+		}
+	})
+	var requests int
+	rt.setHandler(routerHandler(func() ([]string, []string) {
+		requests++
+		if requests == 1 {
+			return nil, []string{addr(old)}
+		}
+		return nil, []string{addr(leader)}
+	}))
+
+	db := sql.OpenDB(NewRoutingConnector(Config{Host: "127.0.0.1", Port: port(rt)}, nil))
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	_, err := db.Exec("CREATE ()")
+	if nerr, ok := err.(*Neo4jError); !ok || !nerr.Retryable() {
+		t.Fatalf("wanted a retryable *Neo4jError, got %v", err)
+	}
+	if _, err := db.Exec("CREATE ()"); err != nil {
+		t.Fatal(err)
+	}
+	if !ran(leader, "CREATE ()") {
+		t.Fatal("wanted the write to run on the new leader")
+	}
+	if n := routingRequests(rt); n != 2 {
+		t.Fatalf("wanted 2 routing table requests, got %d", n)
+	}
+}
+
+func TestRouting_NotALeaderTx(t *testing.T) {
+	old, leader, rt := newStubServer(t), newStubServer(t), newStubServer(t)
+	defer old.Close()
+	defer leader.Close()
+	defer rt.Close()
+	old.setHandler(failQueryHandler("CREATE ()", "Neo.ClientError.Cluster.NotALeader"))
+	var requests int
+	rt.setHandler(routerHandler(func() ([]string, []string) {
+		requests++
+		if requests == 1 {
+			return nil, []string{addr(old)}
+		}
+		return nil, []string{addr(leader)}
+	}))
+
+	db := sql.OpenDB(NewRoutingConnector(Config{Host: "127.0.0.1", Port: port(rt)}, nil))
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tx.Exec("CREATE ()")
+	if nerr, ok := err.(*Neo4jError); !ok || !nerr.Retryable() {
+		t.Fatalf("wanted a retryable *Neo4jError, got %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The connection to the old leader was dropped once the transaction
+	// ended.
+	if _, err := db.Exec("CREATE ()"); err != nil {
+		t.Fatal(err)
+	}
+	if !ran(leader, "CREATE ()") {
+		t.Fatal("wanted the write to run on the new leader")
+	}
+}
+
+func TestRouting_FailedServer(t *testing.T) {
+	writer, rt := newStubServer(t), newStubServer(t)
+	defer writer.Close()
+	defer rt.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead := ln.Addr().String()
+	ln.Close()
+
+	var requests int
+	rt.setHandler(routerHandler(func() ([]string, []string) {
+		requests++
+		if requests == 1 {
+			return nil, []string{dead}
+		}
+		return nil, []string{addr(writer)}
+	}))
+
+	c := NewRoutingConnector(Config{Host: "127.0.0.1", Port: port(rt)}, nil)
+	db := sql.OpenDB(c)
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE ()"); err != nil {
+		t.Fatal(err)
+	}
+	if !ran(writer, "CREATE ()") {
+		t.Fatal("wanted the write to run on the reachable writer")
+	}
+	c.r.mu.Lock()
+	writers := c.r.table.writers
+	c.r.mu.Unlock()
+	if contains(writers, dead) {
+		t.Fatal("wanted the unreachable server to be removed from the routing table")
+	}
+}
+
+func TestRouting_TTL(t *testing.T) {
+	writer, rt := newStubServer(t), newStubServer(t)
+	defer writer.Close()
+	defer rt.Close()
+	rt.setHandler(routerHandler(func() ([]string, []string) {
+		return nil, []string{addr(writer)}
+	}))
+
+	var (
+		mu  sync.Mutex
+		now = time.Now()
+	)
+	c := NewRoutingConnector(Config{Host: "127.0.0.1", Port: port(rt)}, nil)
+	c.r.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	db := sql.OpenDB(c)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("CREATE ()"); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	now = now.Add(301 * time.Second)
+	mu.Unlock()
+	// Connections that are already open aren't affected, so open a new one.
+	db.SetMaxIdleConns(0)
+	if _, err := db.Exec("CREATE ()"); err != nil {
+		t.Fatal(err)
+	}
+	if n := routingRequests(rt); n != 2 {
+		t.Fatalf("wanted 2 routing table requests, got %d", n)
+	}
+}
+
+func TestRouting_Unsupported(t *testing.T) {
+	if _, err := OpenNeo(RoutingScheme + "localhost:7687"); err != ErrRoutingUnsupported {
+		t.Fatalf("wanted ErrRoutingUnsupported, got %v", err)
+	}
+	if _, err := OpenPool(RoutingScheme+"localhost:7687", 1); err != ErrRoutingUnsupported {
+		t.Fatalf("wanted ErrRoutingUnsupported, got %v", err)
+	}
+}
+
+func port(srv *stubServer) string {
+	_, p, _ := net.SplitHostPort(addr(srv))
+	return p
+}
@@ -4,19 +4,48 @@ import (
 	"database/sql/driver"
 	"errors"
 	"io"
+	"sync/atomic"
 
 	"github.com/sermodigital/bolt/structures/graph"
 	"github.com/sermodigital/bolt/structures/messages"
 )
 
-var _ driver.Rows = (*rows)(nil)
+// Rows is the result of a query. Its Neo methods are like those of Conn.
+type Rows interface {
+	driver.Rows
+
+	// Metadata returns the metadata of the response to RUN, which includes
+	// the query's fields.
+	Metadata() map[string]interface{}
+
+	// NextNeo returns the next row. After the last row it returns the
+	// metadata of the response to PULL_ALL and io.EOF.
+	NextNeo() ([]interface{}, map[string]interface{}, error)
+
+	// All returns the remaining rows and the metadata of the response to
+	// PULL_ALL.
+	All() ([][]interface{}, map[string]interface{}, error)
+}
+
+var (
+	_ driver.Rows = (*rows)(nil)
+	_ Rows        = (*rows)(nil)
+)
 
 type rows struct {
 	conn     *conn
 	cols     []string
 	closed   bool // true if Close successfully called.
 	finished bool // true if all rows have been read.
+	runmd    map[string]interface{}
 	md       map[string]interface{}
+
+	// sum receives the metadata of the response to PULL_ALL, like the
+	// bookmark and database, once all rows have been read.
+	sum *Summary
+
+	// finish stops watching the query's context. See conn.watchCancel.
+	finish func() error
 }
 
 // Columns returns the 'fields' returned from the server. It helps implement
@@ -25,6 +54,11 @@ func (r *rows) Columns() []string {
 	return r.cols
 }
 
+// Metadata implements Rows.
+func (r *rows) Metadata() map[string]interface{} {
+	return r.runmd
+}
+
 // Close closes the rows. It helps implement driver.Rows.
 func (r *rows) Close() error {
 	if r.closed {
@@ -32,54 +66,134 @@ func (r *rows) Close() error {
 	}
 	// We haven't read all the rows.
 	if !r.finished {
-		if err := r.conn.dec.Discard(); err != nil {
+		err := r.done(nil)
+		if derr := r.drain(); err == nil {
+			err = derr
+		}
+		if err != nil {
 			return err
 		}
-		r.finished = true
 	}
 	r.closed = true
 	return nil
 }
 
+// done marks the rows as finished and stops watching the query's context. It
+// returns the context's error if the query was canceled, otherwise err.
+func (r *rows) done(err error) error {
+	r.finished = true
+	if r.finish != nil {
+		if cerr := r.finish(); cerr != nil {
+			err = cerr
+		}
+		r.finish = nil
+	}
+	return err
+}
+
+// drain reads the remaining rows, recording the metadata of the response to
+// PULL_ALL, and then discards any other pending responses.
+func (r *rows) drain() error {
+	for atomic.LoadInt32(&r.conn.pending) > 0 {
+		resp, err := r.conn.consume()
+		if err != nil {
+			if _, ok := err.(*Neo4jError); !ok {
+				return err
+			}
+			break
+		}
+		if _, ok := resp.(messages.Record); ok {
+			continue
+		}
+		if success, ok := resp.(messages.Success); ok {
+			r.md = success.Metadata
+			if r.sum != nil {
+				r.sum.parseSuccess(r.md)
+			}
+		}
+		break
+	}
+	return r.conn.discard()
+}
+
 // ErrRowsClosed is returned when the Rows have already been closed.
 var ErrRowsClosed = errors.New("bolt: rows have been closed")
 
 // Next returns the next row. It helps implement driver.Rows.
 func (r *rows) Next(dest []driver.Value) error {
+	row, _, err := r.NextNeo()
+	if err != nil {
+		return err
+	}
+	for i, item := range row {
+		switch item := item.(type) {
+		case driver.Value,
+			Array,
+			Map,
+			graph.Node,
+			graph.Path,
+			graph.Relationship,
+			graph.UnboundRelationship,
+			graph.Date,
+			graph.Time,
+			graph.LocalTime,
+			graph.LocalDateTime,
+			graph.Duration,
+			graph.Point2D,
+			graph.Point3D:
+			dest[i] = item
+		default:
+			dest[i], err = driver.DefaultParameterConverter.ConvertValue(item)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// NextNeo implements Rows.
+func (r *rows) NextNeo() ([]interface{}, map[string]interface{}, error) {
 	if r.closed {
-		return ErrRowsClosed
+		return nil, nil, ErrRowsClosed
+	}
+	if r.finished {
+		return nil, r.md, io.EOF
 	}
 
 	resp, err := r.conn.consume()
 	if err != nil {
-		return err
+		return nil, nil, r.done(err)
 	}
 
 	switch t := resp.(type) {
 	case messages.Success:
 		r.md = t.Metadata
-		r.finished = true
-		return io.EOF
-	case messages.Record:
-		for i, item := range t.Values {
-			switch item := item.(type) {
-			case driver.Value,
-				Array,
-				Map,
-				graph.Node,
-				graph.Path,
-				graph.Relationship,
-				graph.UnboundRelationship:
-				dest[i] = item
-			default:
-				dest[i], err = driver.DefaultParameterConverter.ConvertValue(item)
-				if err != nil {
-					return err
-				}
-			}
+		if r.sum != nil {
+			r.sum.parseSuccess(r.md)
 		}
-		return nil
+		if err := r.done(nil); err != nil {
+			return nil, nil, err
+		}
+		return nil, r.md, io.EOF
+	case messages.Record:
+		return t.Values, nil, nil
 	default:
-		return UnrecognizedResponseErr{v: resp}
+		return nil, nil, r.done(UnrecognizedResponseErr{v: resp})
+	}
+}
+
+// All implements Rows.
+func (r *rows) All() ([][]interface{}, map[string]interface{}, error) {
+	var data [][]interface{}
+	for {
+		row, md, err := r.NextNeo()
+		if err == io.EOF {
+			return data, md, nil
+		}
+		if err != nil {
+			return data, md, err
+		}
+		data = append(data, row)
 	}
 }
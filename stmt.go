@@ -8,17 +8,36 @@ import (
 	"github.com/sermodigital/bolt/structures/messages"
 )
 
+// Stmt is a prepared statement. Its Neo methods are like those of Conn.
+type Stmt interface {
+	driver.Stmt
+
+	// QueryNeo runs the statement's query, which returns rows.
+	QueryNeo(params map[string]interface{}) (Rows, error)
+
+	// ExecNeo runs the statement's query, discarding any rows it returns.
+	ExecNeo(params map[string]interface{}) (driver.Result, error)
+}
+
 var (
 	_ driver.Stmt             = (*stmt)(nil)
 	_ driver.StmtExecContext  = (*stmt)(nil)
 	_ driver.StmtQueryContext = (*stmt)(nil)
+	_ Stmt                    = (*stmt)(nil)
 )
 
 // ErrNotMap is returned when one argument is passed to a Query, Exec, etc.
 // method and its type isn't Map.
 var ErrNotMap = errors.New("bolt: if one argument is passed it must of type Map")
 
+// CheckNamedValue implements driver.NamedValueChecker.
 func (s *stmt) CheckNamedValue(v *driver.NamedValue) error {
+	return checkNamedValue(v)
+}
+
+// checkNamedValue allows any type to be passed as an argument, leaving it to
+// the encoder to reject types it can't handle.
+func checkNamedValue(v *driver.NamedValue) error {
 	if _, ok := v.Value.(Map); ok || v.Name != "" {
 		return nil
 	}
@@ -42,7 +61,11 @@ func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driv
 	if err != nil {
 		return nil, err
 	}
-	return s.runquery(ctx, params)
+	rows, err := s.runquery(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
 }
 
 type stmt struct {
@@ -93,14 +116,20 @@ func (s *stmt) exec(ctx context.Context, args map[string]interface{}) (driver.Re
 	if s.closed {
 		return nil, ErrStatementClosed
 	}
-
-	_, err := s.pull(ctx, args)
-	if err != nil {
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	// Discard any results.
-	_, pull, err := s.conn.consumeAll()
+	finish := s.conn.watchCancel(ctx)
+	var pull interface{}
+	_, err := s.pull(ctx, args)
+	if err == nil {
+		// Discard any results.
+		_, pull, err = s.conn.consumeAll()
+	}
+	if cerr := finish(); cerr != nil {
+		return nil, cerr
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -121,26 +150,62 @@ func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
 	if err != nil {
 		return nil, err
 	}
-	return s.runquery(context.Background(), params)
+	rows, err := s.runquery(context.Background(), params)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// QueryNeo implements Stmt.
+func (s *stmt) QueryNeo(params map[string]interface{}) (Rows, error) {
+	rows, err := s.runquery(context.Background(), params)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ExecNeo implements Stmt.
+func (s *stmt) ExecNeo(params map[string]interface{}) (driver.Result, error) {
+	return s.exec(context.Background(), params)
 }
 
-// runquery is the common implementation of Query and QueryContext. Its naming
-// is different because stmt has a query member.
-func (s *stmt) runquery(ctx context.Context, args map[string]interface{}) (driver.Rows, error) {
+// runquery is the common implementation of Query, QueryContext, and QueryNeo.
+// Its naming is different because stmt has a query member.
+func (s *stmt) runquery(ctx context.Context, args map[string]interface{}) (*rows, error) {
 	if s.closed {
 		return nil, ErrStatementClosed
 	}
-	cols, err := s.pull(ctx, args)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	finish := s.conn.watchCancel(ctx)
+	md, err := s.pull(ctx, args)
 	if err != nil {
+		if cerr := finish(); cerr != nil {
+			return nil, cerr
+		}
 		return nil, err
 	}
-	return &rows{conn: s.conn, cols: cols}, nil
+	return &rows{
+		conn:   s.conn,
+		cols:   parseCols(md),
+		runmd:  md,
+		sum:    fromContext(ctx),
+		finish: finish,
+	}, nil
 }
 
-// pull executes a query and returns any errors that occur. It does not pull
-// any results other than the 'RUN' command.
-func (s *stmt) pull(ctx context.Context, args map[string]interface{}) ([]string, error) {
-	resp, err := s.conn.sendRunPullAllConsumeRun(s.query, args)
+// pull executes a query and returns the metadata of the response to RUN. It
+// does not pull any results other than the 'RUN' command.
+func (s *stmt) pull(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	runmd, err := s.conn.runMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.conn.sendRunPullAllConsumeRun(s.query, args, runmd)
 	if err != nil {
 		s.closed = true
 		return nil, err
@@ -154,5 +219,10 @@ func (s *stmt) pull(ctx context.Context, args map[string]interface{}) ([]string,
 	sum := fromContext(ctx)
 	sum.parseSuccess(md)
 	sum.Query = s.query
-	return parseCols(md), nil
+	if sum.ServerInfo.Version == "" {
+		// As of Bolt v3 the server only identifies itself once, in
+		// response to HELLO.
+		sum.ServerInfo.Version = s.conn.server
+	}
+	return md, nil
 }
@@ -0,0 +1,63 @@
+package graph
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	// DateSignature is the signature byte for a Date object
+	DateSignature = 0x44
+)
+
+// Date Represents a Date structure, a date without a time zone. The
+// underlying time.Time is midnight of the date in UTC.
+type Date time.Time
+
+// NewDate returns the Date for the given year, month and day.
+func NewDate(year int, month time.Month, day int) Date {
+	return Date(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+// DateOf returns the date of t in t's location.
+func DateOf(t time.Time) Date {
+	return NewDate(t.Date())
+}
+
+// Time returns the date as a time.Time.
+func (d Date) Time() time.Time {
+	return time.Time(d)
+}
+
+// Days returns the number of days since the Unix epoch.
+func (d Date) Days() int64 {
+	return time.Time(d).Unix() / secondsPerDay
+}
+
+func (d Date) String() string {
+	return time.Time(d).Format("2006-01-02")
+}
+
+// Signature gets the signature byte for the struct
+func (d Date) Signature() uint8 {
+	return DateSignature
+}
+
+// Fields gets the fields to encode for the struct
+func (d Date) Fields() []interface{} {
+	return []interface{}{d.Days()}
+}
+
+func (d *Date) Scan(val interface{}) error {
+	d0, ok := val.(Date)
+	if !ok {
+		return fmt.Errorf("Date.Scan: unknown type: %T", val)
+	}
+	*d = d0
+	return nil
+}
+
+var _ sql.Scanner = (*Date)(nil)
+
+const secondsPerDay = 24 * 60 * 60
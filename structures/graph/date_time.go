@@ -0,0 +1,21 @@
+package graph
+
+// DateTime structures have no type of their own. The encoding package decodes
+// them to and encodes them from time.Time.
+const (
+	// DateTimeSignature is the signature byte for a DateTime object with a
+	// UTC offset. Its seconds are counted in local time.
+	DateTimeSignature = 0x46
+
+	// DateTimeZoneIDSignature is the signature byte for a DateTime object
+	// with a time zone name. Its seconds are counted in local time.
+	DateTimeZoneIDSignature = 0x66
+
+	// DateTimeUTCSignature is the signature byte for a DateTime object with a
+	// UTC offset as of Bolt v5. Its seconds are counted in UTC.
+	DateTimeUTCSignature = 0x49
+
+	// DateTimeZoneIDUTCSignature is the signature byte for a DateTime object
+	// with a time zone name as of Bolt v5. Its seconds are counted in UTC.
+	DateTimeZoneIDUTCSignature = 0x69
+)
@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	// DurationSignature is the signature byte for a Duration object
+	DurationSignature = 0x45
+)
+
+// Duration Represents a Duration structure. Unlike time.Duration it can hold
+// calendar-based amounts of time, which don't have a fixed length.
+type Duration struct {
+	Months  int64
+	Days    int64
+	Seconds int64
+	Nanos   int64
+}
+
+// DurationOf returns the Duration equivalent of d.
+func DurationOf(d time.Duration) Duration {
+	secs, nanos := int64(d/time.Second), int64(d%time.Second)
+	if nanos < 0 {
+		secs--
+		nanos += int64(time.Second)
+	}
+	return Duration{Seconds: secs, Nanos: nanos}
+}
+
+// Duration returns the Duration as a time.Duration. Months and days are
+// assumed to be 30 and 24 hours long, respectively.
+func (d Duration) Duration() time.Duration {
+	const day = 24 * time.Hour
+	return time.Duration(d.Months)*30*day + time.Duration(d.Days)*day +
+		time.Duration(d.Seconds)*time.Second + time.Duration(d.Nanos)
+}
+
+// String returns the Duration in ISO 8601 format.
+func (d Duration) String() string {
+	return fmt.Sprintf("P%dM%dDT%d.%09dS", d.Months, d.Days, d.Seconds, d.Nanos)
+}
+
+// Signature gets the signature byte for the struct
+func (d Duration) Signature() uint8 {
+	return DurationSignature
+}
+
+// Fields gets the fields to encode for the struct
+func (d Duration) Fields() []interface{} {
+	return []interface{}{d.Months, d.Days, d.Seconds, d.Nanos}
+}
+
+func (d *Duration) Scan(val interface{}) error {
+	d0, ok := val.(Duration)
+	if !ok {
+		return fmt.Errorf("Duration.Scan: unknown type: %T", val)
+	}
+	*d = d0
+	return nil
+}
+
+var _ sql.Scanner = (*Duration)(nil)
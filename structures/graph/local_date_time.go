@@ -0,0 +1,53 @@
+package graph
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	// LocalDateTimeSignature is the signature byte for a LocalDateTime object
+	LocalDateTimeSignature = 0x64
+)
+
+// LocalDateTime Represents a LocalDateTime structure, a date and time without
+// a time zone. The underlying time.Time holds the wall clock reading in UTC.
+type LocalDateTime time.Time
+
+// LocalDateTimeOf returns the wall clock reading of t in t's location.
+func LocalDateTimeOf(t time.Time) LocalDateTime {
+	return LocalDateTime(time.Date(t.Year(), t.Month(), t.Day(),
+		t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC))
+}
+
+// Time returns the date and time as a time.Time.
+func (t LocalDateTime) Time() time.Time {
+	return time.Time(t)
+}
+
+func (t LocalDateTime) String() string {
+	return time.Time(t).Format("2006-01-02T15:04:05.999999999")
+}
+
+// Signature gets the signature byte for the struct
+func (t LocalDateTime) Signature() uint8 {
+	return LocalDateTimeSignature
+}
+
+// Fields gets the fields to encode for the struct
+func (t LocalDateTime) Fields() []interface{} {
+	tt := time.Time(t)
+	return []interface{}{tt.Unix(), int64(tt.Nanosecond())}
+}
+
+func (t *LocalDateTime) Scan(val interface{}) error {
+	t0, ok := val.(LocalDateTime)
+	if !ok {
+		return fmt.Errorf("LocalDateTime.Scan: unknown type: %T", val)
+	}
+	*t = t0
+	return nil
+}
+
+var _ sql.Scanner = (*LocalDateTime)(nil)
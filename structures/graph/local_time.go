@@ -0,0 +1,69 @@
+package graph
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	// LocalTimeSignature is the signature byte for a LocalTime object
+	LocalTimeSignature = 0x74
+)
+
+// LocalTime Represents a LocalTime structure, a time of day without a time
+// zone. The underlying time.Time is on January 1st of year 0 in UTC.
+type LocalTime time.Time
+
+// NewLocalTime returns the LocalTime for the given time of day.
+func NewLocalTime(hour, min, sec, nsec int) LocalTime {
+	return LocalTime(time.Date(0, time.January, 1, hour, min, sec, nsec, time.UTC))
+}
+
+// LocalTimeOf returns the time of day of t in t's location.
+func LocalTimeOf(t time.Time) LocalTime {
+	return NewLocalTime(t.Hour(), t.Minute(), t.Second(), t.Nanosecond())
+}
+
+// Time returns the time of day as a time.Time.
+func (t LocalTime) Time() time.Time {
+	return time.Time(t)
+}
+
+// Nanoseconds returns the number of nanoseconds since midnight.
+func (t LocalTime) Nanoseconds() int64 {
+	return nanosOfDay(time.Time(t))
+}
+
+func (t LocalTime) String() string {
+	return time.Time(t).Format("15:04:05.999999999")
+}
+
+// Signature gets the signature byte for the struct
+func (t LocalTime) Signature() uint8 {
+	return LocalTimeSignature
+}
+
+// Fields gets the fields to encode for the struct
+func (t LocalTime) Fields() []interface{} {
+	return []interface{}{t.Nanoseconds()}
+}
+
+func (t *LocalTime) Scan(val interface{}) error {
+	t0, ok := val.(LocalTime)
+	if !ok {
+		return fmt.Errorf("LocalTime.Scan: unknown type: %T", val)
+	}
+	*t = t0
+	return nil
+}
+
+var _ sql.Scanner = (*LocalTime)(nil)
+
+// nanosOfDay returns the number of nanoseconds between midnight and t's wall
+// clock.
+func nanosOfDay(t time.Time) int64 {
+	h, m, s := t.Clock()
+	return int64(h)*int64(time.Hour) + int64(m)*int64(time.Minute) +
+		int64(s)*int64(time.Second) + int64(t.Nanosecond())
+}
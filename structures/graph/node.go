@@ -15,6 +15,9 @@ type Node struct {
 	NodeIdentity int64
 	Labels       []string
 	Properties   map[string]interface{}
+	// ElementID identifies the node as of Bolt v5. Unlike NodeIdentity, it is
+	// not reused after the node is deleted.
+	ElementID string
 }
 
 // Signature gets the signature byte for the struct.
@@ -28,6 +31,9 @@ func (n Node) Fields() []interface{} {
 	for i, label := range n.Labels {
 		labels[i] = label
 	}
+	if n.ElementID != "" {
+		return []interface{}{n.NodeIdentity, labels, n.Properties, n.ElementID}
+	}
 	return []interface{}{n.NodeIdentity, labels, n.Properties}
 }
 
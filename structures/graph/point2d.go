@@ -0,0 +1,40 @@
+package graph
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+const (
+	// Point2DSignature is the signature byte for a Point2D object
+	Point2DSignature = 0x58
+)
+
+// Point2D Represents a Point2D structure, a point in a two-dimensional
+// coordinate reference system identified by SRID.
+type Point2D struct {
+	SRID int64
+	X    float64
+	Y    float64
+}
+
+// Signature gets the signature byte for the struct
+func (p Point2D) Signature() uint8 {
+	return Point2DSignature
+}
+
+// Fields gets the fields to encode for the struct
+func (p Point2D) Fields() []interface{} {
+	return []interface{}{p.SRID, p.X, p.Y}
+}
+
+func (p *Point2D) Scan(val interface{}) error {
+	p0, ok := val.(Point2D)
+	if !ok {
+		return fmt.Errorf("Point2D.Scan: unknown type: %T", val)
+	}
+	*p = p0
+	return nil
+}
+
+var _ sql.Scanner = (*Point2D)(nil)
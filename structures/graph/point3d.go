@@ -0,0 +1,41 @@
+package graph
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+const (
+	// Point3DSignature is the signature byte for a Point3D object
+	Point3DSignature = 0x59
+)
+
+// Point3D Represents a Point3D structure, a point in a three-dimensional
+// coordinate reference system identified by SRID.
+type Point3D struct {
+	SRID int64
+	X    float64
+	Y    float64
+	Z    float64
+}
+
+// Signature gets the signature byte for the struct
+func (p Point3D) Signature() uint8 {
+	return Point3DSignature
+}
+
+// Fields gets the fields to encode for the struct
+func (p Point3D) Fields() []interface{} {
+	return []interface{}{p.SRID, p.X, p.Y, p.Z}
+}
+
+func (p *Point3D) Scan(val interface{}) error {
+	p0, ok := val.(Point3D)
+	if !ok {
+		return fmt.Errorf("Point3D.Scan: unknown type: %T", val)
+	}
+	*p = p0
+	return nil
+}
+
+var _ sql.Scanner = (*Point3D)(nil)
@@ -17,6 +17,11 @@ type Relationship struct {
 	EndNodeIdentity   int64
 	Type              string
 	Properties        map[string]interface{}
+	// ElementID, StartNodeElementID and EndNodeElementID identify the
+	// relationship and its nodes as of Bolt v5.
+	ElementID          string
+	StartNodeElementID string
+	EndNodeElementID   string
 }
 
 // Signature gets the signature byte for the struct
@@ -26,6 +31,12 @@ func (r Relationship) Signature() uint8 {
 
 // Fields gets the fields to encode for the struct
 func (r Relationship) Fields() []interface{} {
+	if r.ElementID != "" {
+		return []interface{}{
+			r.RelIdentity, r.StartNodeIdentity, r.EndNodeIdentity, r.Type, r.Properties,
+			r.ElementID, r.StartNodeElementID, r.EndNodeElementID,
+		}
+	}
 	return []interface{}{r.RelIdentity, r.StartNodeIdentity, r.EndNodeIdentity, r.Type, r.Properties}
 }
 
@@ -0,0 +1,70 @@
+package graph
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	// TimeSignature is the signature byte for a Time object
+	TimeSignature = 0x54
+)
+
+// Time Represents a Time structure, a time of day with a UTC offset. The
+// underlying time.Time is on January 1st of year 0 in a fixed zone.
+type Time time.Time
+
+// NewTime returns the Time for the given time of day and offset, in seconds
+// east of UTC.
+func NewTime(hour, min, sec, nsec, offset int) Time {
+	loc := time.FixedZone("", offset)
+	return Time(time.Date(0, time.January, 1, hour, min, sec, nsec, loc))
+}
+
+// TimeOf returns the time of day and UTC offset of t.
+func TimeOf(t time.Time) Time {
+	_, offset := t.Zone()
+	return NewTime(t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), offset)
+}
+
+// Time returns the time of day as a time.Time.
+func (t Time) Time() time.Time {
+	return time.Time(t)
+}
+
+// Nanoseconds returns the number of nanoseconds since midnight.
+func (t Time) Nanoseconds() int64 {
+	return nanosOfDay(time.Time(t))
+}
+
+// Offset returns the time's offset in seconds east of UTC.
+func (t Time) Offset() int {
+	_, offset := time.Time(t).Zone()
+	return offset
+}
+
+func (t Time) String() string {
+	return time.Time(t).Format("15:04:05.999999999Z07:00")
+}
+
+// Signature gets the signature byte for the struct
+func (t Time) Signature() uint8 {
+	return TimeSignature
+}
+
+// Fields gets the fields to encode for the struct
+func (t Time) Fields() []interface{} {
+	return []interface{}{t.Nanoseconds(), int64(t.Offset())}
+}
+
+func (t *Time) Scan(val interface{}) error {
+	t0, ok := val.(Time)
+	if !ok {
+		return fmt.Errorf("Time.Scan: unknown type: %T", val)
+	}
+	*t = t0
+	return nil
+}
+
+var _ sql.Scanner = (*Time)(nil)
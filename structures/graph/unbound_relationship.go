@@ -15,6 +15,8 @@ type UnboundRelationship struct {
 	RelIdentity int64
 	Type        string
 	Properties  map[string]interface{}
+	// ElementID identifies the relationship as of Bolt v5.
+	ElementID string
 }
 
 // Signature gets the signature byte for the struct
@@ -24,6 +26,9 @@ func (r UnboundRelationship) Signature() uint8 {
 
 // Fields gets the fields to encode for the struct
 func (r UnboundRelationship) Fields() []interface{} {
+	if r.ElementID != "" {
+		return []interface{}{r.RelIdentity, r.Type, r.Properties, r.ElementID}
+	}
 	return []interface{}{r.RelIdentity, r.Type, r.Properties}
 }
 
@@ -0,0 +1,29 @@
+package messages
+
+const (
+	// BeginSignature is the signature byte for the BEGIN message
+	BeginSignature = 0x11
+)
+
+// Begin Represents a BEGIN message
+type Begin struct {
+	Metadata map[string]interface{}
+}
+
+// NewBeginMessage Gets a new Begin struct
+func NewBeginMessage(metadata map[string]interface{}) Begin {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	return Begin{Metadata: metadata}
+}
+
+// Signature gets the signature byte for the struct
+func (i Begin) Signature() uint8 {
+	return BeginSignature
+}
+
+// Fields gets the fields to encode for the struct
+func (i Begin) Fields() []interface{} {
+	return []interface{}{i.Metadata}
+}
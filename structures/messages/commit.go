@@ -0,0 +1,19 @@
+package messages
+
+const (
+	// CommitSignature is the signature byte for the COMMIT message
+	CommitSignature = 0x12
+)
+
+// Commit Represents a COMMIT message
+type Commit struct{}
+
+// Signature gets the signature byte for the struct
+func (i Commit) Signature() uint8 {
+	return CommitSignature
+}
+
+// Fields gets the fields to encode for the struct
+func (i Commit) Fields() []interface{} {
+	return nil
+}
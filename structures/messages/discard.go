@@ -0,0 +1,28 @@
+package messages
+
+const (
+	// DiscardSignature is the signature byte for the DISCARD message. DISCARD
+	// replaces DISCARD_ALL as of Bolt v4.
+	DiscardSignature = 0x2F
+)
+
+// Discard Represents a DISCARD message
+type Discard struct {
+	Metadata map[string]interface{}
+}
+
+// NewDiscardMessage Gets a new Discard struct discarding n records. If n is -1
+// all records are discarded.
+func NewDiscardMessage(n int64) Discard {
+	return Discard{Metadata: map[string]interface{}{"n": n}}
+}
+
+// Signature gets the signature byte for the struct
+func (i Discard) Signature() uint8 {
+	return DiscardSignature
+}
+
+// Fields gets the fields to encode for the struct
+func (i Discard) Fields() []interface{} {
+	return []interface{}{i.Metadata}
+}
@@ -0,0 +1,19 @@
+package messages
+
+const (
+	// GoodbyeSignature is the signature byte for the GOODBYE message
+	GoodbyeSignature = 0x02
+)
+
+// Goodbye Represents a GOODBYE message
+type Goodbye struct{}
+
+// Signature gets the signature byte for the struct
+func (i Goodbye) Signature() uint8 {
+	return GoodbyeSignature
+}
+
+// Fields gets the fields to encode for the struct
+func (i Goodbye) Fields() []interface{} {
+	return nil
+}
@@ -0,0 +1,38 @@
+package messages
+
+const (
+	// HelloSignature is the signature byte for the HELLO message. HELLO
+	// replaces INIT as of Bolt v3.
+	HelloSignature = 0x01
+)
+
+// Hello Represents a HELLO message
+type Hello struct {
+	Metadata map[string]interface{}
+}
+
+// NewHelloMessage Gets a new Hello struct
+func NewHelloMessage(userAgent string, user string, password string) Hello {
+	return NewHelloMessageWithToken(userAgent, authToken(user, password))
+}
+
+// NewHelloMessageWithToken Gets a new Hello struct that authenticates with
+// token. token is copied and not modified.
+func NewHelloMessageWithToken(userAgent string, token map[string]interface{}) Hello {
+	md := make(map[string]interface{}, len(token)+1)
+	for k, v := range token {
+		md[k] = v
+	}
+	md["user_agent"] = userAgent
+	return Hello{Metadata: md}
+}
+
+// Signature gets the signature byte for the struct
+func (i Hello) Signature() uint8 {
+	return HelloSignature
+}
+
+// Fields gets the fields to encode for the struct
+func (i Hello) Fields() []interface{} {
+	return []interface{}{i.Metadata}
+}
@@ -13,17 +13,25 @@ type Init struct {
 
 // NewInit Gets a new Init struct
 func NewInitMessage(clientName string, user string, password string) Init {
-	msg := Init{clientName: clientName}
+	return NewInitMessageWithToken(clientName, authToken(user, password))
+}
+
+// NewInitMessageWithToken Gets a new Init struct that authenticates with
+// token, e.g. {"scheme": "bearer", "credentials": "..."}
+func NewInitMessageWithToken(clientName string, token map[string]interface{}) Init {
+	return Init{clientName: clientName, authToken: token}
+}
+
+// authToken builds the authentication token sent by INIT and HELLO.
+func authToken(user string, password string) map[string]interface{} {
 	if user == "" {
-		msg.authToken = map[string]interface{}{"scheme": "none"}
-	} else {
-		msg.authToken = map[string]interface{}{
-			"scheme":      "basic",
-			"principal":   user,
-			"credentials": password,
-		}
+		return map[string]interface{}{"scheme": "none"}
+	}
+	return map[string]interface{}{
+		"scheme":      "basic",
+		"principal":   user,
+		"credentials": password,
 	}
-	return msg
 }
 
 // Signature gets the signature byte for the struct
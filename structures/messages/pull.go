@@ -0,0 +1,28 @@
+package messages
+
+const (
+	// PullSignature is the signature byte for the PULL message. PULL replaces
+	// PULL_ALL as of Bolt v4.
+	PullSignature = 0x3F
+)
+
+// Pull Represents a PULL message
+type Pull struct {
+	Metadata map[string]interface{}
+}
+
+// NewPullMessage Gets a new Pull struct requesting n records. If n is -1 all
+// records are requested.
+func NewPullMessage(n int64) Pull {
+	return Pull{Metadata: map[string]interface{}{"n": n}}
+}
+
+// Signature gets the signature byte for the struct
+func (i Pull) Signature() uint8 {
+	return PullSignature
+}
+
+// Fields gets the fields to encode for the struct
+func (i Pull) Fields() []interface{} {
+	return []interface{}{i.Metadata}
+}
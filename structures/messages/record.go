@@ -22,5 +22,5 @@ func (i Record) Signature() uint8 {
 
 // Fields gets the fields to encode for the struct
 func (i Record) Fields() []interface{} {
-	return i.Values
+	return []interface{}{i.Values}
 }
@@ -0,0 +1,19 @@
+package messages
+
+const (
+	// RollbackSignature is the signature byte for the ROLLBACK message
+	RollbackSignature = 0x13
+)
+
+// Rollback Represents a ROLLBACK message
+type Rollback struct{}
+
+// Signature gets the signature byte for the struct
+func (i Rollback) Signature() uint8 {
+	return RollbackSignature
+}
+
+// Fields gets the fields to encode for the struct
+func (i Rollback) Fields() []interface{} {
+	return nil
+}
@@ -9,6 +9,7 @@ const (
 type Run struct {
 	statement  string
 	parameters map[string]interface{}
+	metadata   map[string]interface{}
 }
 
 // NewRun Gets a new Run struct
@@ -16,6 +17,15 @@ func NewRunMessage(statement string, parameters map[string]interface{}) Run {
 	return Run{statement: statement, parameters: parameters}
 }
 
+// NewRunMessageWithMetadata Gets a new Run struct carrying the extra metadata
+// field added in Bolt v3.
+func NewRunMessageWithMetadata(statement string, parameters, metadata map[string]interface{}) Run {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	return Run{statement: statement, parameters: parameters, metadata: metadata}
+}
+
 // Signature gets the signature byte for the struct
 func (i Run) Signature() uint8 {
 	return RunSignature
@@ -23,5 +33,8 @@ func (i Run) Signature() uint8 {
 
 // Fields gets the fields to encode for the struct
 func (i Run) Fields() []interface{} {
+	if i.metadata != nil {
+		return []interface{}{i.statement, i.parameters, i.metadata}
+	}
 	return []interface{}{i.statement, i.parameters}
 }
@@ -17,6 +17,12 @@ type Summary struct {
 	AvailableAfter time.Duration
 	ConsumedAfter  time.Duration
 	ServerInfo     ServerInfo
+	// Database is the name of the database the query ran against. It is
+	// only reported as of Bolt v4.
+	Database string
+	// Bookmark identifies the transaction committed by an auto-commit query.
+	// It is only reported as of Bolt v3. See WithBookmarks.
+	Bookmark string
 }
 
 func (s *Summary) parseSuccess(md map[string]interface{}) {
@@ -45,16 +51,29 @@ func (s *Summary) parseSuccess(md map[string]interface{}) {
 		}
 	}
 
-	if v, ok := md["result_available_after"].(int64); ok {
-		s.AvailableAfter = time.Duration(v) * time.Millisecond
+	// Bolt v3 renamed result_available_after and result_consumed_after.
+	for _, key := range [...]string{"result_available_after", "t_first"} {
+		if v, ok := md[key].(int64); ok {
+			s.AvailableAfter = time.Duration(v) * time.Millisecond
+		}
 	}
-	if v, ok := md["result_consumed_after"].(int64); ok {
-		s.ConsumedAfter = time.Duration(v) * time.Millisecond
+	for _, key := range [...]string{"result_consumed_after", "t_last"} {
+		if v, ok := md[key].(int64); ok {
+			s.ConsumedAfter = time.Duration(v) * time.Millisecond
+		}
 	}
 
 	if vers, ok := md["server"].(string); ok {
 		s.ServerInfo.Version = vers
 	}
+
+	if db, ok := md["db"].(string); ok {
+		s.Database = db
+	}
+
+	if bm, ok := md["bookmark"].(string); ok {
+		s.Bookmark = bm
+	}
 }
 
 // ServerInfo describes basic information on the server that ran the query.
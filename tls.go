@@ -0,0 +1,262 @@
+package bolt
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCertificatePin is returned when none of the certificates presented by
+// the server or in its verified chains match the SHA-256 fingerprints in
+// TLSOptions.Pins.
+var ErrCertificatePin = errors.New("bolt: server certificate does not match a pinned fingerprint")
+
+// TLSOptions configures the Dialer returned by NewTLSDialer.
+type TLSOptions struct {
+	// CACertFile is the path of a PEM encoded CA bundle used to verify the
+	// server's certificate instead of the system's CAs.
+	CACertFile string
+
+	// CertFile and KeyFile are the paths of a PEM encoded client
+	// certificate and its private key.
+	CertFile string
+	KeyFile  string
+
+	// NoVerify skips the verification of the server's certificate. Pins are
+	// still checked.
+	NoVerify bool
+
+	// MinVersion and MaxVersion are the versions of TLS to accept, e.g.
+	// tls.VersionTLS13. MinVersion defaults to tls.VersionTLS12 and
+	// MaxVersion to the highest version supported by crypto/tls.
+	MinVersion uint16
+	MaxVersion uint16
+
+	// ServerName is sent with SNI and used to verify the server's
+	// certificate. It defaults to the host being dialed.
+	ServerName string
+
+	// CipherSuites restricts the cipher suites negotiated with TLS 1.2 and
+	// earlier. crypto/tls doesn't allow TLS 1.3's to be configured.
+	CipherSuites []uint16
+
+	// Pins are hex encoded SHA-256 fingerprints of certificates, which may
+	// be separated by colons. If set, a certificate the server presents or,
+	// unless NoVerify is set, one in the chain it was verified with, like
+	// the root CA, must match one of them.
+	Pins []string
+
+	// Reload, if positive, is how often CACertFile, CertFile and KeyFile
+	// are re-read, so new connections use rotated credentials without the
+	// Dialer being recreated. If a file can't be read or parsed the
+	// previous credentials are kept.
+	Reload time.Duration
+}
+
+// NewTLSDialer returns a Dialer that connects with TLS configured by opts.
+// Unlike TLSDialer it doesn't read environment variables.
+func NewTLSDialer(opts TLSOptions) (Dialer, error) {
+	cfg := &tls.Config{
+		MinVersion:         opts.MinVersion,
+		MaxVersion:         opts.MaxVersion,
+		ServerName:         opts.ServerName,
+		CipherSuites:       opts.CipherSuites,
+		InsecureSkipVerify: opts.NoVerify,
+	}
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+
+	certs := &certFiles{
+		caFile:   opts.CACertFile,
+		certFile: opts.CertFile,
+		keyFile:  opts.KeyFile,
+		interval: opts.Reload,
+	}
+	if err := certs.load(); err != nil {
+		return nil, err
+	}
+	certs.loaded = time.Now()
+	cfg.RootCAs = certs.roots
+	if certs.cert != nil {
+		cfg.Certificates = []tls.Certificate{*certs.cert}
+	}
+
+	if len(opts.Pins) > 0 {
+		pins := make([][]byte, len(opts.Pins))
+		for i, pin := range opts.Pins {
+			var err error
+			if pins[i], err = parsePin(pin); err != nil {
+				return nil, err
+			}
+		}
+		cfg.VerifyConnection = verifyPins(pins)
+	}
+
+	if opts.Reload <= 0 {
+		return &dialer{cfg: cfg}, nil
+	}
+	if certs.certFile != "" {
+		cfg.Certificates = nil
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			_, cert := certs.credentials()
+			return cert, nil
+		}
+	}
+	return &dialer{cfg: cfg, certs: certs}, nil
+}
+
+// TLSConfigDialer returns a Dialer that connects with TLS configured by a
+// copy of cfg. A nil cfg is treated like an empty tls.Config, so the server's
+// certificate is verified against the system's CAs.
+func TLSConfigDialer(cfg *tls.Config) Dialer {
+	if cfg == nil {
+		return &dialer{cfg: &tls.Config{}}
+	}
+	return &dialer{cfg: cfg.Clone()}
+}
+
+// parsePin parses a hex encoded SHA-256 fingerprint.
+func parsePin(pin string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.Replace(strings.TrimSpace(pin), ":", "", -1))
+	if err != nil || len(b) != sha256.Size {
+		return nil, fmt.Errorf("bolt: invalid SHA-256 fingerprint: %q", pin)
+	}
+	return b, nil
+}
+
+// verifyPins returns a tls.Config.VerifyConnection func that checks the
+// server's certificates against pins. Servers don't usually send their root
+// CA, so the chains the server's certificate was verified with are checked
+// too.
+func verifyPins(pins [][]byte) func(tls.ConnectionState) error {
+	pinned := func(certs []*x509.Certificate) bool {
+		for _, cert := range certs {
+			sum := sha256.Sum256(cert.Raw)
+			for _, pin := range pins {
+				if string(sum[:]) == string(pin) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return func(cs tls.ConnectionState) error {
+		if pinned(cs.PeerCertificates) {
+			return nil
+		}
+		for _, chain := range cs.VerifiedChains {
+			if pinned(chain) {
+				return nil
+			}
+		}
+		return ErrCertificatePin
+	}
+}
+
+// parseTLSVersion parses the tls_min_version parameter, e.g. "1.3".
+func parseTLSVersion(s string) (uint16, error) {
+	switch s {
+	case "":
+		return 0, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("bolt: unsupported TLS version: %q", s)
+	}
+}
+
+// certFiles holds the credentials read from a CA bundle and a client
+// certificate and key.
+type certFiles struct {
+	caFile   string
+	certFile string
+	keyFile  string
+	interval time.Duration // how often the files are re-read
+
+	mu     sync.Mutex
+	loaded time.Time
+	roots  *x509.CertPool
+	cert   *tls.Certificate
+}
+
+// load reads the files. f is only modified if they're all valid.
+func (f *certFiles) load() error {
+	var (
+		roots *x509.CertPool
+		cert  *tls.Certificate
+	)
+	if f.caFile != "" {
+		pem, err := ioutil.ReadFile(f.caFile)
+		if err != nil {
+			return err
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return errors.New("could not append CA certificate")
+		}
+	}
+	if f.certFile != "" {
+		if f.keyFile == "" {
+			return errors.New("cert file requires a key file")
+		}
+		c, err := tls.LoadX509KeyPair(f.certFile, f.keyFile)
+		if err != nil {
+			return err
+		}
+		cert = &c
+	}
+	f.roots, f.cert = roots, cert
+	return nil
+}
+
+// credentials returns the CA bundle and client certificate, re-reading the
+// files if they haven't been read within the reload interval.
+func (f *certFiles) credentials() (*x509.CertPool, *tls.Certificate) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if time.Since(f.loaded) >= f.interval {
+		// If the files are being replaced and can't be read yet the
+		// previous credentials are kept.
+		f.load()
+		f.loaded = time.Now()
+	}
+	return f.roots, f.cert
+}
+
+// verify returns a tls.Config.VerifyPeerCertificate func that verifies the
+// server's certificate for name against the current CA bundle.
+func (f *certFiles) verify(name string) func([][]byte, [][]*x509.Certificate) error {
+	return func(raw [][]byte, _ [][]*x509.Certificate) error {
+		if len(raw) == 0 {
+			return errors.New("bolt: server presented no certificates")
+		}
+		certs := make([]*x509.Certificate, len(raw))
+		for i, b := range raw {
+			var err error
+			if certs[i], err = x509.ParseCertificate(b); err != nil {
+				return err
+			}
+		}
+		roots, _ := f.credentials()
+		opts := x509.VerifyOptions{
+			DNSName:       name,
+			Roots:         roots,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range certs[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(opts)
+		return err
+	}
+}
@@ -0,0 +1,400 @@
+package bolt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"database/sql"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCert is a certificate for 127.0.0.1 and localhost.
+type testCert struct {
+	cert     tls.Certificate
+	certFile string // PEM encoded certificate
+	keyFile  string // PEM encoded private key
+}
+
+// newTestCert returns a self-signed testCert, which is also a CA.
+func newTestCert(t *testing.T) testCert {
+	t.Helper()
+	return issueTestCert(t, nil)
+}
+
+// issueTestCert returns a testCert issued by ca, or a self-signed CA if ca
+// is nil.
+func issueTestCert(t *testing.T, ca *testCert) testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:              []string{"localhost"},
+	}
+	parent, signer := tmpl, interface{}(key)
+	if ca != nil {
+		tmpl.IsCA = false
+		tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+		parent, signer = ca.cert.Leaf, ca.cert.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	dir := t.TempDir()
+	c := testCert{
+		certFile: filepath.Join(dir, "cert.pem"),
+		keyFile:  filepath.Join(dir, "key.pem"),
+	}
+	if err := ioutil.WriteFile(c.certFile, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(c.keyFile, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if c.cert, err = tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// newTLSStubServer starts a stubServer that only accepts TLS connections,
+// using cfg.
+func newTLSStubServer(t *testing.T, cfg *tls.Config) *stubServer {
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return serveStub(ln)
+}
+
+func TestTLS_DSN(t *testing.T) {
+	cert := newTestCert(t)
+	srv := newTLSStubServer(t, &tls.Config{Certificates: []tls.Certificate{cert.cert}})
+	defer srv.Close()
+
+	c, err := Open(srv.dsn() + "?tls=1&tls_ca_cert_file=" + cert.certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+
+	if _, err := Open(srv.dsn() + "?tls=1"); err == nil {
+		t.Fatal("wanted an error verifying a self-signed certificate")
+	}
+
+	c, err = Open(srv.dsn() + "?tls=true&tls_no_verify=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+
+	if _, err := Open(srv.dsn() + "?tls=yes"); err == nil {
+		t.Fatal("wanted an error for an invalid tls parameter")
+	}
+}
+
+func TestTLS_ClientCert(t *testing.T) {
+	cert := newTestCert(t)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert.cert.Leaf)
+	srv := newTLSStubServer(t, &tls.Config{
+		Certificates: []tls.Certificate{cert.cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	})
+	defer srv.Close()
+
+	dsn := srv.dsn() + "?tls=1&tls_ca_cert_file=" + cert.certFile
+	c, err := Open(dsn + "&tls_cert_file=" + cert.certFile + "&tls_key_file=" + cert.keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+
+	if _, err := Open(dsn); err == nil {
+		t.Fatal("wanted an error connecting without a client certificate")
+	}
+}
+
+func TestTLS_EnvPrecedence(t *testing.T) {
+	t.Setenv(TLSEnv, "1")
+	for dsn, want := range map[string]bool{
+		"bolt://localhost:7687":       true,
+		"bolt://localhost:7687?tls=0": false,
+	} {
+		v, err := parseDSN(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := newDialer(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := d.(*dialer).cfg != nil; got != want {
+			t.Fatalf("%s: wanted TLS to be %t, got %t", dsn, want, got)
+		}
+	}
+}
+
+func TestTLS_Schemes(t *testing.T) {
+	cert := newTestCert(t)
+	srv := newTLSStubServer(t, &tls.Config{Certificates: []tls.Certificate{cert.cert}})
+	defer srv.Close()
+	srv.setHandler(routerHandler(func() ([]string, []string) {
+		return []string{addr(srv)}, []string{addr(srv)}
+	}))
+
+	for _, dsn := range []string{
+		SelfSignedScheme + addr(srv),
+		SecureScheme + addr(srv) + "?tls_ca_cert_file=" + cert.certFile,
+	} {
+		c, err := Open(dsn)
+		if err != nil {
+			t.Fatalf("%s: %v", dsn, err)
+		}
+		c.Close()
+	}
+
+	for _, dsn := range []string{
+		SecureScheme + addr(srv),
+		SecureScheme + addr(srv) + "?tls=0",
+		SelfSignedScheme + addr(srv) + "?tls_no_verify=0",
+		"http://" + addr(srv),
+	} {
+		if c, err := Open(dsn); err == nil {
+			c.Close()
+			t.Fatalf("%s: wanted an error", dsn)
+		}
+	}
+
+	db, err := sql.Open(DefaultDriver, SelfSignedRoutingScheme+addr(srv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("CREATE ()"); err != nil {
+		t.Fatal(err)
+	}
+	if !ran(srv, "CREATE ()") {
+		t.Fatal("wanted the write to be routed over TLS")
+	}
+}
+
+func TestTLS_Options(t *testing.T) {
+	cert := newTestCert(t)
+	srv := newTLSStubServer(t, &tls.Config{Certificates: []tls.Certificate{cert.cert}})
+	defer srv.Close()
+	sum := sha256.Sum256(cert.cert.Leaf.Raw)
+	pin := hex.EncodeToString(sum[:])
+
+	open := func(d Dialer) (*conn, error) {
+		c, err := DialOpen(d, srv.dsn())
+		if err != nil {
+			return nil, err
+		}
+		return c.(*conn), nil
+	}
+
+	d, err := NewTLSDialer(TLSOptions{CACertFile: cert.certFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := open(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := c.conn.(*tls.Conn).ConnectionState().Version; v != tls.VersionTLS13 {
+		t.Fatalf("wanted TLS 1.3 to be negotiated, got %x", v)
+	}
+	c.Close()
+
+	for _, tc := range []struct {
+		name string
+		opts TLSOptions
+		ok   bool
+	}{
+		{"pin", TLSOptions{NoVerify: true, Pins: []string{pin}}, true},
+		{"wrong pin", TLSOptions{NoVerify: true, Pins: []string{strings.Repeat("00", 32)}}, false},
+		{"server name", TLSOptions{CACertFile: cert.certFile, ServerName: "localhost"}, true},
+		{"wrong server name", TLSOptions{CACertFile: cert.certFile, ServerName: "example.com"}, false},
+		{"max version", TLSOptions{NoVerify: true, MaxVersion: tls.VersionTLS12}, true},
+	} {
+		d, err := NewTLSDialer(tc.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		c, err := open(d)
+		if (err == nil) != tc.ok {
+			t.Fatalf("%s: wanted success to be %t, got %v", tc.name, tc.ok, err)
+		}
+		if err == nil {
+			c.Close()
+		}
+	}
+
+	if _, err := NewTLSDialer(TLSOptions{Pins: []string{"abc"}}); err == nil {
+		t.Fatal("wanted an error for an invalid pin")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert.cert.Leaf)
+	c, err = open(TLSConfigDialer(&tls.Config{RootCAs: pool}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+
+	// A nil tls.Config must not fall back to plaintext.
+	if d := TLSConfigDialer(nil).(*dialer); d.cfg == nil {
+		t.Fatal("wanted TLSConfigDialer(nil) to use TLS")
+	}
+
+	c2, err := Open(srv.dsn() + "?tls=1&tls_no_verify=1&tls_min_version=1.3&tls_pins=" + pin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2.Close()
+}
+
+func TestTLS_PinCA(t *testing.T) {
+	ca := newTestCert(t)
+	leaf := issueTestCert(t, &ca)
+	// The server doesn't send its root CA.
+	srv := newTLSStubServer(t, &tls.Config{Certificates: []tls.Certificate{leaf.cert}})
+	defer srv.Close()
+	sum := sha256.Sum256(ca.cert.Leaf.Raw)
+	pin := hex.EncodeToString(sum[:])
+
+	d, err := NewTLSDialer(TLSOptions{CACertFile: ca.certFile, Pins: []string{pin}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := DialOpen(d, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+
+	// Without verification there's no chain to find the CA in.
+	d, err = NewTLSDialer(TLSOptions{NoVerify: true, Pins: []string{pin}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DialOpen(d, srv.dsn()); err == nil {
+		t.Fatal("wanted the CA pin to fail without verification")
+	}
+}
+
+func TestTLS_MinVersion(t *testing.T) {
+	cert := newTestCert(t)
+	srv := newTLSStubServer(t, &tls.Config{
+		Certificates: []tls.Certificate{cert.cert},
+		MaxVersion:   tls.VersionTLS11,
+	})
+	defer srv.Close()
+
+	if c, err := Open(srv.dsn() + "?tls=1&tls_no_verify=1"); err == nil {
+		c.Close()
+		t.Fatal("wanted TLS 1.1 to be refused")
+	}
+}
+
+func TestTLS_Reload(t *testing.T) {
+	cert1, cert2 := newTestCert(t), newTestCert(t)
+	copyFile := func(dst, src string) {
+		t.Helper()
+		b, err := ioutil.ReadFile(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(dst, b, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	copyFile(caFile, cert1.certFile)
+	copyFile(certFile, cert1.certFile)
+	copyFile(keyFile, cert1.keyFile)
+
+	d, err := NewTLSDialer(TLSOptions{
+		CACertFile: caFile,
+		CertFile:   certFile,
+		KeyFile:    keyFile,
+		Reload:     time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The server's certificate is rotated and it requires a client
+	// certificate issued by its new CA.
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert2.cert.Leaf)
+	srv := newTLSStubServer(t, &tls.Config{
+		Certificates: []tls.Certificate{cert2.cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	})
+	defer srv.Close()
+
+	if c, err := DialOpen(d, srv.dsn()); err == nil {
+		c.Close()
+		t.Fatal("wanted an error before the credentials were rotated")
+	}
+
+	copyFile(caFile, cert2.certFile)
+	copyFile(certFile, cert2.certFile)
+	copyFile(keyFile, cert2.keyFile)
+	c, err := DialOpen(d, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+
+	// Credentials that can't be read don't replace the previous ones.
+	if err := ioutil.WriteFile(caFile, []byte("garbage"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err = DialOpen(d, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+}
@@ -1,9 +1,14 @@
 package bolt
 
 import (
+	"context"
+	"database/sql"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/sermodigital/bolt/structures/graph"
+	"github.com/sermodigital/bolt/structures/messages"
 )
 
 func TestBoltTx_Commit(t *testing.T) {
@@ -163,3 +168,133 @@ func TestBoltTx_Rollback(t *testing.T) {
 		t.Fatalf("error closing connection: %s", err)
 	}
 }
+
+func TestBoltTx_Options(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+	srv.version = version3_0
+	srv.setHandler(v3Handler)
+
+	db, err := sql.Open(DefaultDriver, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := WithTxTimeout(context.Background(), 2*time.Second)
+	ctx = WithTxMetadata(ctx, map[string]interface{}{"app": "test"})
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, "RETURN 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	begins := srv.messages(messages.BeginSignature)
+	if len(begins) != 1 {
+		t.Fatalf("wanted 1 BEGIN, got %d", len(begins))
+	}
+	want := map[string]interface{}{
+		"mode":        "r",
+		"tx_timeout":  int64(2000),
+		"tx_metadata": map[string]interface{}{"app": "test"},
+	}
+	if md := begins[0].fields[0]; !reflect.DeepEqual(md, want) {
+		t.Fatalf("wanted BEGIN metadata %v, got %v", want, md)
+	}
+
+	// An auto-commit query carries the configuration with its RUN.
+	runs := srv.messages(messages.RunSignature)
+	md := runs[len(runs)-1].fields[2].(map[string]interface{})
+	if md["tx_timeout"] != int64(2000) || md["mode"] != nil {
+		t.Fatalf("wrong RUN metadata: %v", md)
+	}
+
+	_, err = db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err == nil {
+		t.Fatal("wanted an error for an unsupported isolation level")
+	}
+}
+
+func TestBoltTx_OptionsUnsupported(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+
+	db, err := sql.Open(DefaultDriver, srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := WithTxTimeout(context.Background(), time.Second)
+	if _, err := db.BeginTx(ctx, nil); err != ErrTxConfigUnsupported {
+		t.Fatalf("wanted ErrTxConfigUnsupported, got %v", err)
+	}
+}
+
+// failQueryHandler is like failingHandler but fails the given query with
+// code.
+func failQueryHandler(query, code string) func(uint8, []interface{}) []interface{} {
+	var failed bool
+	return func(sig uint8, fields []interface{}) []interface{} {
+		success := []interface{}{messages.Success{Metadata: map[string]interface{}{}}}
+		switch {
+		case sig == messages.AckFailureSignature, sig == messages.ResetSignature:
+			failed = false
+			return success
+		case failed:
+			return []interface{}{messages.Ignored{}}
+		case sig == messages.RunSignature && fields[0] == query:
+			failed = true
+			return []interface{}{messages.Failure{Metadata: map[string]interface{}{
+				"code":    code,
+				"message": "The query failed.",
+			}}}
+		}
+		return success
+	}
+}
+
+func TestBoltTx_Failure(t *testing.T) {
+	srv := newStubServer(t)
+	defer srv.Close()
+
+	srv.setHandler(failQueryHandler("BEGIN", "Neo.TransientError.Transaction.Terminated"))
+	c, err := OpenNeo(srv.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	cn := c.(*conn)
+	if _, err := cn.Begin(); err == nil {
+		t.Fatal("wanted BEGIN to fail")
+	} else if _, ok := err.(*Neo4jError); !ok {
+		t.Fatalf("wanted a *Neo4jError, got %v", err)
+	}
+	if cn.status != statusIdle {
+		t.Fatalf("wanted a failed BEGIN to leave the connection idle, got %v", cn.status)
+	}
+
+	srv.setHandler(failQueryHandler("COMMIT", "Neo.TransientError.Transaction.Terminated"))
+	if _, err := cn.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cn.Commit(); err == nil {
+		t.Fatal("wanted COMMIT to fail")
+	} else if _, ok := err.(*Neo4jError); !ok {
+		t.Fatalf("wanted a *Neo4jError, got %v", err)
+	}
+	if cn.status != statusInBadTx {
+		t.Fatalf("wanted a failed COMMIT to leave a bad transaction, got %v", cn.status)
+	}
+	if err := cn.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if cn.status != statusIdle {
+		t.Fatalf("wanted the rollback to leave the connection idle, got %v", cn.status)
+	}
+}